@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/globusdigital/deep-copy/deepcopy"
+)
+
+// outputCache stores the generated files by the fingerprint of the package
+// they were generated from, so that regenerating an unchanged package skips
+// loading its types.
+type outputCache struct {
+	dir string
+}
+
+// cachedOutput is a generated file, along with its tests if they were
+// emitted. The import path of the package and the types generated are kept
+// for the manifest.
+type cachedOutput struct {
+	Code    []byte   `json:"code"`
+	Tests   []byte   `json:"tests,omitempty"`
+	Package string   `json:"package"`
+	Types   []string `json:"types"`
+}
+
+// key returns the cache key of the output generated from the package with the
+// fingerprint. Besides the package, the output depends on the arguments,
+// configuration and header files of the tool, and on the tool itself.
+func (c outputCache) key(fingerprint string) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "fingerprint %s\n", fingerprint)
+	fmt.Fprintf(h, "args %q\n", os.Args[1:])
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "wd %s\n", wd)
+
+	files := []string{}
+	if exe, err := os.Executable(); err == nil {
+		files = append(files, exe)
+	}
+	for _, path := range []string{*configFileF, *headerFileF} {
+		if path = strings.TrimSpace(path); path != "" {
+			files = append(files, path)
+		}
+	}
+	for _, path := range files {
+		if err := hashFile(h, path); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Fprintf(w, "file %s\n", path)
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// lookup returns the cache key of the output generated from the package at
+// path, along with the output stored under it, if any.
+func (c outputCache) lookup(cfg deepcopy.LoadConfig, path string) (string, cachedOutput, bool, error) {
+	fingerprint, err := deepcopy.Fingerprint(cfg, path)
+	if err != nil {
+		return "", cachedOutput{}, false, err
+	}
+
+	key, err := c.key(fingerprint)
+	if err != nil {
+		return "", cachedOutput{}, false, err
+	}
+
+	out, ok, err := c.get(key)
+	return key, out, ok, err
+}
+
+// get returns the output stored under the key, if any.
+func (c outputCache) get(key string) (cachedOutput, bool, error) {
+	var out cachedOutput
+
+	b, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return out, false, nil
+	}
+	if err != nil {
+		return out, false, err
+	}
+
+	if err := json.Unmarshal(b, &out); err != nil {
+		// A corrupted entry is regenerated.
+		return out, false, nil
+	}
+
+	return out, true, nil
+}
+
+// put stores the output under the key.
+func (c outputCache) put(key string, out cachedOutput) error {
+	b, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0o777); err != nil {
+		return err
+	}
+
+	// The entry is written to a temporary file first, so that concurrent
+	// runs never read a partial entry.
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+func (c outputCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
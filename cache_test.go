@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_outputCache(t *testing.T) {
+	c := outputCache{dir: t.TempDir() + "/cache"}
+
+	if _, ok, err := c.get("missing"); ok || err != nil {
+		t.Fatalf("get() = %v, %v, want a miss", ok, err)
+	}
+
+	want := cachedOutput{Code: []byte("package p\n"), Tests: []byte("package p\n\nimport \"testing\"\n")}
+	if err := c.put("key", want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok, err := c.get("key")
+	if !ok || err != nil {
+		t.Fatalf("get() = %v, %v, want a hit", ok, err)
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("get() diff = %s", diff)
+	}
+
+	k1, err := c.key("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := c.key("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k1 == k2 {
+		t.Errorf("key() = %s for different fingerprints", k1)
+	}
+}
+
+func Test_timings(t *testing.T) {
+	var untimed *timings
+	untimed.since("load", time.Now())
+
+	tm := timings{{phase: "load", duration: 1500 * time.Millisecond}, {phase: "generate", duration: 20 * time.Millisecond}}
+	if got, want := tm.String(), "load 1.5s, generate 20ms"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,16 @@
+// copycheck reports hand-written deep copy methods which leave pointer, slice,
+// map or interface fields of their receiver shared with the original.
+//
+// Usage:
+//
+//	copycheck [-method DeepCopy] ./...
+package main
+
+import (
+	"github.com/globusdigital/deep-copy/deepcopy/copycheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(copycheck.Analyzer)
+}
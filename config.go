@@ -13,11 +13,35 @@ type config struct {
 	PointerReceiver *bool   `yaml:"pointer-receiver,omitempty"`
 	MaxDepth        *int    `yaml:"maxdepth,omitempty"`
 	Method          *string `yaml:"method,omitempty"`
+	Markers         *bool   `yaml:"markers,omitempty"`
+	EmitTests       *bool   `yaml:"emit-tests,omitempty"`
+	GoVersion       *string `yaml:"go-version,omitempty"`
+	All             *bool   `yaml:"all,omitempty"`
+	TypePattern     *string `yaml:"type-pattern,omitempty"`
+	Tests           *bool   `yaml:"tests,omitempty"`
+	GOOS            *string `yaml:"goos,omitempty"`
+	GOARCH          *string `yaml:"goarch,omitempty"`
+	Overlay         *string `yaml:"overlay,omitempty"`
+	ExportData      *bool   `yaml:"export-data,omitempty"`
+	CacheDir        *string `yaml:"cache-dir,omitempty"`
+	Timing          *bool   `yaml:"timing,omitempty"`
+	Jobs            *int    `yaml:"jobs,omitempty"`
+	Equal           *bool   `yaml:"equal,omitempty"`
+	EqualNilEmpty   *bool   `yaml:"equal-nil-empty,omitempty"`
+	Diff            *bool   `yaml:"diff,omitempty"`
+	Size            *bool   `yaml:"size,omitempty"`
+	Merge           *bool   `yaml:"merge,omitempty"`
+	Manifest        *string `yaml:"manifest,omitempty"`
+	HeaderFile      *string `yaml:"header-file,omitempty"`
+	Header          *string `yaml:"header,omitempty"`
 
-	Types      []string `yaml:"type,omitempty"`
-	Skips      []string `yaml:"skip,omitempty"`
-	OutputPath *string  `yaml:"output,omitempty"`
-	BuildTags  []string `yaml:"build-tags,omitempty"`
+	Types        []string `yaml:"type,omitempty"`
+	ExcludeTypes []string `yaml:"exclude-type,omitempty"`
+	Skips        []string `yaml:"skip,omitempty"`
+	OutputPath   *string  `yaml:"output,omitempty"`
+	BuildTags    []string `yaml:"build-tags,omitempty"`
+	LoadTags     []string `yaml:"load-tags,omitempty"`
+	Converts     []string `yaml:"convert,omitempty"`
 }
 
 func loadConfig() error {
@@ -50,10 +74,40 @@ func loadConfigFile(configPath string, flagsSetOnCLI map[string]struct{}) error
 	mergePtr(flagsSetOnCLI, "pointer-receiver", cfg.PointerReceiver, pointerReceiverF)
 	mergePtr(flagsSetOnCLI, "maxdepth", cfg.MaxDepth, maxDepthF)
 	mergePtr(flagsSetOnCLI, "method", cfg.Method, methodF)
+	mergePtr(flagsSetOnCLI, "markers", cfg.Markers, markersF)
+	mergePtr(flagsSetOnCLI, "emit-tests", cfg.EmitTests, emitTestsF)
+	mergePtr(flagsSetOnCLI, "go-version", cfg.GoVersion, goVersionF)
+	mergePtr(flagsSetOnCLI, "all", cfg.All, allF)
+	mergePtr(flagsSetOnCLI, "type-pattern", cfg.TypePattern, typePatternF)
+	mergePtr(flagsSetOnCLI, "tests", cfg.Tests, testsF)
+	mergePtr(flagsSetOnCLI, "goos", cfg.GOOS, goosF)
+	mergePtr(flagsSetOnCLI, "goarch", cfg.GOARCH, goarchF)
+	mergePtr(flagsSetOnCLI, "overlay", cfg.Overlay, overlayF)
+	mergePtr(flagsSetOnCLI, "export-data", cfg.ExportData, exportDataF)
+	mergePtr(flagsSetOnCLI, "cache-dir", cfg.CacheDir, cacheDirF)
+	mergePtr(flagsSetOnCLI, "timing", cfg.Timing, timingF)
+	mergePtr(flagsSetOnCLI, "j", cfg.Jobs, jobsF)
+	mergePtr(flagsSetOnCLI, "equal", cfg.Equal, equalF)
+	mergePtr(flagsSetOnCLI, "equal-nil-empty", cfg.EqualNilEmpty, nilEqualsEmptyF)
+	mergePtr(flagsSetOnCLI, "diff", cfg.Diff, diffF)
+	mergePtr(flagsSetOnCLI, "size", cfg.Size, sizeF)
+	mergePtr(flagsSetOnCLI, "merge", cfg.Merge, mergeF)
+	mergePtr(flagsSetOnCLI, "manifest", cfg.Manifest, manifestF)
+	mergePtr(flagsSetOnCLI, "header-file", cfg.HeaderFile, headerFileF)
+
+	if cfg.Header != nil && cfg.HeaderFile != nil {
+		return fmt.Errorf("header and header-file are mutually exclusive")
+	}
+	if cfg.Header != nil && !flagWasSetOnCLI(flagsSetOnCLI, "header-file") {
+		headerText = *cfg.Header
+	}
 
 	if len(cfg.Types) > 0 && !flagWasSetOnCLI(flagsSetOnCLI, "type") {
 		typesF = typesVal(cfg.Types)
 	}
+	if len(cfg.ExcludeTypes) > 0 && !flagWasSetOnCLI(flagsSetOnCLI, "exclude-type") {
+		excludeTypesF = typesVal(cfg.ExcludeTypes)
+	}
 	if len(cfg.Skips) > 0 && !flagWasSetOnCLI(flagsSetOnCLI, "skip") {
 		skipsF = skipsVal{}
 		for _, skip := range cfg.Skips {
@@ -70,6 +124,17 @@ func loadConfigFile(configPath string, flagsSetOnCLI map[string]struct{}) error
 	if len(cfg.BuildTags) > 0 && !flagWasSetOnCLI(flagsSetOnCLI, "tags") {
 		buildTagsF = buildTagsVal(cfg.BuildTags)
 	}
+	if len(cfg.LoadTags) > 0 && !flagWasSetOnCLI(flagsSetOnCLI, "load-tags") {
+		loadTagsF = buildTagsVal(cfg.LoadTags)
+	}
+	if len(cfg.Converts) > 0 && !flagWasSetOnCLI(flagsSetOnCLI, "convert") {
+		convertsF = convertsVal{}
+		for _, conv := range cfg.Converts {
+			if err := convertsF.Set(conv); err != nil {
+				return fmt.Errorf("parsing convert value: %w", err)
+			}
+		}
+	}
 
 	return nil
 }
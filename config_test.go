@@ -19,9 +19,33 @@ type globalsSnapshot struct {
 	pointerReceiver bool
 	maxDepth        int
 	method          string
+	markers         bool
+	emitTests       bool
+	goVersion       string
+	all             bool
+	typePattern     string
+	tests           bool
+	goos            string
+	goarch          string
+	overlay         string
+	exportData      bool
+	cacheDir        string
+	timing          bool
+	jobs            int
+	equal           bool
+	nilEqualsEmpty  bool
+	diff            bool
+	size            bool
+	merge           bool
+	manifest        string
+	headerFile      string
+	headerText      string
 	types           typesVal
+	excludeTypes    typesVal
 	skips           skipsVal
 	buildTags       buildTagsVal
+	loadTags        buildTagsVal
+	converts        convertsVal
 	output          outputVal
 }
 
@@ -30,9 +54,33 @@ func captureGlobals() globalsSnapshot {
 		pointerReceiver: *pointerReceiverF,
 		maxDepth:        *maxDepthF,
 		method:          *methodF,
+		markers:         *markersF,
+		emitTests:       *emitTestsF,
+		goVersion:       *goVersionF,
+		all:             *allF,
+		typePattern:     *typePatternF,
+		tests:           *testsF,
+		goos:            *goosF,
+		goarch:          *goarchF,
+		overlay:         *overlayF,
+		exportData:      *exportDataF,
+		cacheDir:        *cacheDirF,
+		timing:          *timingF,
+		jobs:            *jobsF,
+		equal:           *equalF,
+		nilEqualsEmpty:  *nilEqualsEmptyF,
+		diff:            *diffF,
+		size:            *sizeF,
+		merge:           *mergeF,
+		manifest:        *manifestF,
+		headerFile:      *headerFileF,
+		headerText:      headerText,
 		types:           append(typesVal(nil), typesF...),
+		excludeTypes:    append(typesVal(nil), excludeTypesF...),
 		skips:           cloneSkips(skipsF),
 		buildTags:       append(buildTagsVal(nil), buildTagsF...),
+		loadTags:        append(buildTagsVal(nil), loadTagsF...),
+		converts:        append(convertsVal(nil), convertsF...),
 		output:          outputF,
 	}
 }
@@ -42,9 +90,33 @@ func restoreGlobals(s globalsSnapshot) {
 	*pointerReceiverF = s.pointerReceiver
 	*maxDepthF = s.maxDepth
 	*methodF = s.method
+	*markersF = s.markers
+	*emitTestsF = s.emitTests
+	*goVersionF = s.goVersion
+	*allF = s.all
+	*typePatternF = s.typePattern
+	*testsF = s.tests
+	*goosF = s.goos
+	*goarchF = s.goarch
+	*overlayF = s.overlay
+	*exportDataF = s.exportData
+	*cacheDirF = s.cacheDir
+	*timingF = s.timing
+	*jobsF = s.jobs
+	*equalF = s.equal
+	*nilEqualsEmptyF = s.nilEqualsEmpty
+	*diffF = s.diff
+	*sizeF = s.size
+	*mergeF = s.merge
+	*manifestF = s.manifest
+	*headerFileF = s.headerFile
+	headerText = s.headerText
 	typesF = append(typesVal(nil), s.types...)
+	excludeTypesF = append(typesVal(nil), s.excludeTypes...)
 	skipsF = cloneSkips(s.skips)
 	buildTagsF = append(buildTagsVal(nil), s.buildTags...)
+	loadTagsF = append(buildTagsVal(nil), s.loadTags...)
+	convertsF = append(convertsVal(nil), s.converts...)
 	outputF = s.output
 }
 
@@ -53,9 +125,33 @@ func resetGlobalsForConfigTest() {
 	*pointerReceiverF = false
 	*maxDepthF = 0
 	*methodF = "DeepCopy"
+	*markersF = false
+	*emitTestsF = false
+	*goVersionF = ""
+	*allF = false
+	*typePatternF = ""
+	*testsF = false
+	*goosF = ""
+	*goarchF = ""
+	*overlayF = ""
+	*exportDataF = false
+	*cacheDirF = ""
+	*timingF = false
+	*jobsF = 1
+	*equalF = false
+	*nilEqualsEmptyF = false
+	*diffF = false
+	*sizeF = false
+	*mergeF = false
+	*manifestF = ""
+	*headerFileF = ""
+	headerText = ""
 	typesF = nil
+	excludeTypesF = nil
 	skipsF = nil
 	buildTagsF = nil
+	loadTagsF = nil
+	convertsF = nil
 	outputF = outputVal{}
 }
 
@@ -72,13 +168,37 @@ type configTestCLI struct {
 
 // configTestWant is the expected flag state after loadConfigFile.
 type configTestWant struct {
-	Pointer    *bool
-	MaxDepth   *int
-	Method     *string
-	Types      typesVal
-	Skips      skipsVal
-	BuildTags  buildTagsVal
-	OutputName string // empty = stdout
+	Pointer       *bool
+	MaxDepth      *int
+	Method        *string
+	Markers       *bool
+	EmitTests     *bool
+	GoVersion     *string
+	All           *bool
+	Pattern       *string
+	Tests         *bool
+	GOOS          *string
+	GOARCH        *string
+	Overlay       *string
+	ExportData    *bool
+	CacheDir      *string
+	Timing        *bool
+	Jobs          *int
+	Equal         *bool
+	EqualNilEmpty *bool
+	Diff          *bool
+	Size          *bool
+	Merge         *bool
+	Manifest      *string
+	HeaderFile    *string
+	Header        *string
+	Types         typesVal
+	Exclude       typesVal
+	Skips         skipsVal
+	BuildTags     buildTagsVal
+	LoadTags      buildTagsVal
+	Converts      convertsVal
+	OutputName    string // empty = stdout
 }
 
 func cloneSkips(s skipsVal) skipsVal {
@@ -163,6 +283,84 @@ func assertGlobalsMatchWant(t *testing.T, want configTestWant, wantOutputName st
 	if want.Method != nil && *methodF != *want.Method {
 		t.Errorf("methodF = %v, want %v", *methodF, *want.Method)
 	}
+	if want.Markers != nil && *markersF != *want.Markers {
+		t.Errorf("markersF = %v, want %v", *markersF, *want.Markers)
+	}
+	if want.EmitTests != nil && *emitTestsF != *want.EmitTests {
+		t.Errorf("emitTestsF = %v, want %v", *emitTestsF, *want.EmitTests)
+	}
+	if want.GoVersion != nil && *goVersionF != *want.GoVersion {
+		t.Errorf("goVersionF = %v, want %v", *goVersionF, *want.GoVersion)
+	}
+	if want.All != nil && *allF != *want.All {
+		t.Errorf("allF = %v, want %v", *allF, *want.All)
+	}
+	if want.Pattern != nil && *typePatternF != *want.Pattern {
+		t.Errorf("typePatternF = %v, want %v", *typePatternF, *want.Pattern)
+	}
+	if want.Tests != nil && *testsF != *want.Tests {
+		t.Errorf("testsF = %v, want %v", *testsF, *want.Tests)
+	}
+	if want.GOOS != nil && *goosF != *want.GOOS {
+		t.Errorf("goosF = %v, want %v", *goosF, *want.GOOS)
+	}
+	if want.GOARCH != nil && *goarchF != *want.GOARCH {
+		t.Errorf("goarchF = %v, want %v", *goarchF, *want.GOARCH)
+	}
+	if want.Overlay != nil && *overlayF != *want.Overlay {
+		t.Errorf("overlayF = %v, want %v", *overlayF, *want.Overlay)
+	}
+	if want.ExportData != nil && *exportDataF != *want.ExportData {
+		t.Errorf("exportDataF = %v, want %v", *exportDataF, *want.ExportData)
+	}
+	if want.CacheDir != nil && *cacheDirF != *want.CacheDir {
+		t.Errorf("cacheDirF = %v, want %v", *cacheDirF, *want.CacheDir)
+	}
+	if want.Timing != nil && *timingF != *want.Timing {
+		t.Errorf("timingF = %v, want %v", *timingF, *want.Timing)
+	}
+	if want.Jobs != nil && *jobsF != *want.Jobs {
+		t.Errorf("jobsF = %v, want %v", *jobsF, *want.Jobs)
+	}
+	if want.Equal != nil && *equalF != *want.Equal {
+		t.Errorf("equalF = %v, want %v", *equalF, *want.Equal)
+	}
+	if want.EqualNilEmpty != nil && *nilEqualsEmptyF != *want.EqualNilEmpty {
+		t.Errorf("nilEqualsEmptyF = %v, want %v", *nilEqualsEmptyF, *want.EqualNilEmpty)
+	}
+	if want.Diff != nil && *diffF != *want.Diff {
+		t.Errorf("diffF = %v, want %v", *diffF, *want.Diff)
+	}
+	if want.Size != nil && *sizeF != *want.Size {
+		t.Errorf("sizeF = %v, want %v", *sizeF, *want.Size)
+	}
+	if want.Merge != nil && *mergeF != *want.Merge {
+		t.Errorf("mergeF = %v, want %v", *mergeF, *want.Merge)
+	}
+	if want.Manifest != nil && *manifestF != *want.Manifest {
+		t.Errorf("manifestF = %v, want %v", *manifestF, *want.Manifest)
+	}
+	if want.HeaderFile != nil && *headerFileF != *want.HeaderFile {
+		t.Errorf("headerFileF = %v, want %v", *headerFileF, *want.HeaderFile)
+	}
+	if want.Header != nil && headerText != *want.Header {
+		t.Errorf("headerText = %q, want %q", headerText, *want.Header)
+	}
+	if want.LoadTags != nil {
+		if diff := cmp.Diff(loadTagsF, want.LoadTags); diff != "" {
+			t.Errorf("loadTagsF (-got +want):\n%s", diff)
+		}
+	}
+	if want.Converts != nil {
+		if diff := cmp.Diff(convertsF, want.Converts); diff != "" {
+			t.Errorf("convertsF (-got +want):\n%s", diff)
+		}
+	}
+	if want.Exclude != nil {
+		if diff := cmp.Diff(excludeTypesF, want.Exclude); diff != "" {
+			t.Errorf("excludeTypesF (-got +want):\n%s", diff)
+		}
+	}
 	if want.Types != nil {
 		if diff := cmp.Diff(typesF, want.Types); diff != "" {
 			t.Errorf("typesF (-got +want):\n%s", diff)
@@ -229,9 +427,36 @@ typo-field: true`,
 			configYAML: `pointer-receiver: true
 maxdepth: 5
 method: Clone
+markers: true
+emit-tests: true
+go-version: "1.21"
+all: true
+type-pattern: Req$
+tests: true
+goos: linux
+goarch: arm64
+overlay: overlay.json
+export-data: true
+cache-dir: .cache/deep-copy
+timing: true
+jobs: 4
+equal: true
+equal-nil-empty: true
+diff: true
+size: true
+merge: true
+manifest: deep-copy.manifest.json
+header-file: header.tmpl
+load-tags:
+  - enterprise
+convert:
+  - domain.Order:api.Order
+  - api.Order:domain.Order=OrderFromAPI
 type:
   - A
   - B
+exclude-type:
+  - C
 skip:
   - Field1,Field2
   - Field3
@@ -240,10 +465,36 @@ build-tags:
   - t2`,
 			wantErr: false,
 			want: configTestWant{
-				Pointer:  ptr(true),
-				MaxDepth: ptr(5),
-				Method:   ptr("Clone"),
-				Types:    typesVal{"A", "B"},
+				Pointer:       ptr(true),
+				MaxDepth:      ptr(5),
+				Method:        ptr("Clone"),
+				Markers:       ptr(true),
+				EmitTests:     ptr(true),
+				GoVersion:     ptr("1.21"),
+				All:           ptr(true),
+				Pattern:       ptr("Req$"),
+				Tests:         ptr(true),
+				GOOS:          ptr("linux"),
+				GOARCH:        ptr("arm64"),
+				Overlay:       ptr("overlay.json"),
+				ExportData:    ptr(true),
+				CacheDir:      ptr(".cache/deep-copy"),
+				Timing:        ptr(true),
+				Jobs:          ptr(4),
+				Equal:         ptr(true),
+				EqualNilEmpty: ptr(true),
+				Diff:          ptr(true),
+				Size:          ptr(true),
+				Merge:         ptr(true),
+				Manifest:      ptr("deep-copy.manifest.json"),
+				HeaderFile:    ptr("header.tmpl"),
+				LoadTags:      buildTagsVal{"enterprise"},
+				Converts: convertsVal{
+					{From: "domain.Order", To: "api.Order"},
+					{From: "api.Order", To: "domain.Order", Func: "OrderFromAPI"},
+				},
+				Types:   typesVal{"A", "B"},
+				Exclude: typesVal{"C"},
 				Skips: skipsVal{
 					{"Field1": {}, "Field2": {}},
 					{"Field3": {}},
@@ -251,6 +502,29 @@ build-tags:
 				BuildTags: buildTagsVal{"t1", "t2"},
 			},
 		},
+		{
+			name: "inline header template",
+			configYAML: `header: |
+  Copyright ACME Corp.
+  Package {{.Package}}.`,
+			want: configTestWant{
+				Header: ptr("Copyright ACME Corp.\nPackage {{.Package}}."),
+			},
+		},
+		{
+			name: "header and header-file are mutually exclusive",
+			configYAML: `header: Copyright ACME Corp.
+header-file: header.tmpl`,
+			wantErr: true,
+		},
+		{
+			name:          "CLI header-file flag overrides the inline header",
+			configYAML:    `header: Copyright ACME Corp.`,
+			flagsSetOnCLI: cliFlagsSet("header-file"),
+			want: configTestWant{
+				Header: ptr(""),
+			},
+		},
 		{
 			name: "CLI method flag is not overwritten by config",
 			configYAML: `method: FromConfig
@@ -0,0 +1,152 @@
+package deepcopy
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ChangeKind is the kind of a Change.
+type ChangeKind int
+
+const (
+	// Modified is a value differing between both versions.
+	Modified ChangeKind = iota
+	// Added is a slice element or map entry only the new version has.
+	Added
+	// Removed is a slice element or map entry only the old version has.
+	Removed
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Modified:
+		return "modified"
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", int(k))
+	}
+}
+
+// Change is a difference between two versions of a value, as reported by the
+// Diff methods generated with WithDiff.
+// The generated code imports this package for it, so the module of the
+// generated package requires this one.
+type Change struct {
+	// Selector is the path of the changed member, in the syntax of the skip
+	// selectors, as in "B.I", "Map[k]" or "Slice[i]". It is empty for the
+	// value itself.
+	Selector string
+	// Keys are the slice indexes and map keys the [i] and [k] of the
+	// selector stand for, in order.
+	Keys []any
+
+	Kind ChangeKind
+	// Old and New are the member in the old and new versions. Old is nil
+	// when the member was added, and New when it was removed.
+	Old, New any
+}
+
+// changePath is the import path of the package declaring Change, which the
+// generated Diff methods import.
+var changePath = reflect.TypeOf(Change{}).PkgPath()
+
+// Path returns the selector with its [i] and [k] replaced by the keys, as in
+// `Map["a"].Slice[2]`.
+func (c Change) Path() string {
+	var b strings.Builder
+	keys := c.Keys
+	for sel := c.Selector; sel != ""; {
+		if len(keys) > 0 && (strings.HasPrefix(sel, "[i]") || strings.HasPrefix(sel, "[k]")) {
+			fmt.Fprintf(&b, "[%#v]", keys[0])
+			keys, sel = keys[1:], sel[3:]
+			continue
+		}
+
+		b.WriteByte(sel[0])
+		sel = sel[1:]
+	}
+
+	return b.String()
+}
+
+func (c Change) String() string {
+	path := c.Path()
+	if path == "" {
+		path = "value"
+	}
+
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("%s: added %v", path, c.New)
+	case Removed:
+		return fmt.Sprintf("%s: removed %v", path, c.Old)
+	default:
+		return fmt.Sprintf("%s: %v -> %v", path, c.Old, c.New)
+	}
+}
+
+// NestChanges returns the changes of the member at selector, whose [i] and
+// [k] stand for keys, as changes of the value holding it. The generated Diff
+// methods use it for the members having a Diff method of their own.
+func NestChanges(selector string, keys []any, changes []Change) []Change {
+	for i, c := range changes {
+		switch {
+		case c.Selector == "":
+			c.Selector = selector
+		case selector == "", strings.HasPrefix(c.Selector, "["):
+			c.Selector = selector + c.Selector
+		default:
+			c.Selector = selector + "." + c.Selector
+		}
+
+		if len(keys) > 0 {
+			c.Keys = append(slices.Clone(keys), c.Keys...)
+		}
+
+		changes[i] = c
+	}
+
+	return changes
+}
+
+// SortChanges sorts the changes by their keys, keeping the order of the ones
+// with the same keys. The generated Diff methods sort the changes of the
+// entries of maps by it, so that they are reported in the same order for the
+// same values.
+func SortChanges(changes []Change) {
+	slices.SortStableFunc(changes, func(a, b Change) int {
+		for i := 0; i < len(a.Keys) && i < len(b.Keys); i++ {
+			if c := compareKeys(a.Keys[i], b.Keys[i]); c != 0 {
+				return c
+			}
+		}
+
+		return len(a.Keys) - len(b.Keys)
+	})
+}
+
+// compareKeys orders numbers and strings by value, and the other keys by
+// their Go syntax representation.
+func compareKeys(a, b any) int {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.IsValid() && vb.IsValid() && va.Kind() == vb.Kind() {
+		switch va.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return cmp.Compare(va.Int(), vb.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return cmp.Compare(va.Uint(), vb.Uint())
+		case reflect.Float32, reflect.Float64:
+			return cmp.Compare(va.Float(), vb.Float())
+		case reflect.String:
+			return strings.Compare(va.String(), vb.String())
+		}
+	}
+
+	return strings.Compare(fmt.Sprintf("%#v", a), fmt.Sprintf("%#v", b))
+}
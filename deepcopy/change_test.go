@@ -0,0 +1,67 @@
+package deepcopy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeString(t *testing.T) {
+	tests := []struct {
+		change Change
+		want   string
+	}{
+		{change: Change{Selector: "B.I", Old: 1, New: 2}, want: "B.I: 1 -> 2"},
+		{change: Change{Selector: "Map[k].Slice[i]", Keys: []any{"a", 2}, Kind: Added, New: "x"}, want: `Map["a"].Slice[2]: added x`},
+		{change: Change{Selector: "[i]", Keys: []any{0}, Kind: Removed, Old: "x"}, want: "[0]: removed x"},
+		{change: Change{Selector: "Slice[i]", Old: 1, New: 2}, want: "Slice[i]: 1 -> 2"},
+		{change: Change{Old: nil, New: 2}, want: "value: <nil> -> 2"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.change.String())
+	}
+}
+
+func TestNestChanges(t *testing.T) {
+	changes := []Change{
+		{Old: 1, New: 2},
+		{Selector: "I", Old: 1, New: 2},
+		{Selector: "[k]", Keys: []any{"a"}, Kind: Added, New: 2},
+	}
+
+	assert.Equal(t, []Change{
+		{Selector: "Slice[i]", Keys: []any{3}, Old: 1, New: 2},
+		{Selector: "Slice[i].I", Keys: []any{3}, Old: 1, New: 2},
+		{Selector: "Slice[i][k]", Keys: []any{3, "a"}, Kind: Added, New: 2},
+	}, NestChanges("Slice[i]", []any{3}, changes))
+
+	assert.Equal(t, []Change{{Selector: "B", Old: 1, New: 2}}, NestChanges("B", nil, []Change{{Old: 1, New: 2}}))
+}
+
+func TestSortChanges(t *testing.T) {
+	type key struct{ A int }
+	changes := []Change{
+		{Selector: "M[k].B", Keys: []any{"b"}, Old: 1, New: 2},
+		{Selector: "M[k].A", Keys: []any{"b"}, Old: 1, New: 2},
+		{Selector: "M[k][i]", Keys: []any{"a", 10}, Kind: Added, New: 2},
+		{Selector: "M[k][i]", Keys: []any{"a", 9}, Kind: Removed, Old: 2},
+		{Selector: "M[k]", Keys: []any{"a"}, Old: 1, New: 2},
+		{Selector: "S[k]", Keys: []any{key{2}}, Old: 1, New: 2},
+		{Selector: "S[k]", Keys: []any{key{1}}, Old: 1, New: 2},
+	}
+
+	SortChanges(changes)
+	var paths []string
+	for _, c := range changes {
+		paths = append(paths, c.Path())
+	}
+	assert.Equal(t, []string{
+		`M["a"]`,
+		`M["a"][9]`,
+		`M["a"][10]`,
+		`M["b"].B`,
+		`M["b"].A`,
+		`S[deepcopy.key{A:1}]`,
+		`S[deepcopy.key{A:2}]`,
+	}, paths)
+}
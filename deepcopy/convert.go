@@ -0,0 +1,503 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Conversion is a struct to struct converter to generate, from a type to
+// another with the same fields.
+type Conversion struct {
+	// From and To are the source and destination types, qualified by the
+	// name or import path of their package, as in "domain.Order" or
+	// "example.com/app/api.Order". Unqualified types are looked up in the
+	// package being generated.
+	From, To string
+	// Func is the name of the generated function. It defaults to Convert
+	// followed by the name of the destination type.
+	Func string
+}
+
+// ParseConversion parses a conversion in the syntax of --convert:
+// "From:To", optionally followed by "=Func".
+func ParseConversion(s string) (Conversion, error) {
+	spec, fn, _ := strings.Cut(s, "=")
+	from, to, ok := strings.Cut(spec, ":")
+	if !ok || from == "" || to == "" {
+		return Conversion{}, fmt.Errorf("invalid conversion %q, want From:To or From:To=Func", s)
+	}
+	if fn != "" && !token.IsIdentifier(fn) {
+		return Conversion{}, fmt.Errorf("invalid conversion %q: %q is not a function name", s, fn)
+	}
+
+	return Conversion{From: from, To: to, Func: fn}, nil
+}
+
+func (c Conversion) String() string {
+	s := c.From + ":" + c.To
+	if c.Func != "" {
+		s += "=" + c.Func
+	}
+
+	return s
+}
+
+// convertTag is the struct tag key renaming or leaving out a field of a
+// conversion, as in `deepcopy:"name=Other"` or `deepcopy:"-"`.
+const convertTag = "deepcopy"
+
+// conversion is a resolved Conversion.
+type conversion struct {
+	from, to *types.Named
+	fn       string
+}
+
+// locateConversions resolves the conversions to generate in the package.
+func (g Generator) locateConversions(p *packages.Package) ([]conversion, error) {
+	convs := make([]conversion, len(g.conversions))
+	fns := map[string]Conversion{}
+	for i, c := range g.conversions {
+		from, err := lookupStruct(c.From, p)
+		if err != nil {
+			return nil, fmt.Errorf("locating type %q of conversion %s: %v", c.From, c, err)
+		}
+		to, err := lookupStruct(c.To, p)
+		if err != nil {
+			return nil, fmt.Errorf("locating type %q of conversion %s: %v", c.To, c, err)
+		}
+
+		fn := c.Func
+		if fn == "" {
+			fn = "Convert" + to.Obj().Name()
+		}
+		if other, ok := fns[fn]; ok {
+			return nil, fmt.Errorf("conversions %s and %s both generate %s, name one with =Func", other, c, fn)
+		}
+		fns[fn] = c
+
+		convs[i] = conversion{from: from, to: to, fn: fn}
+	}
+
+	return convs, nil
+}
+
+// lookupStruct looks the named struct type kind up in the package, or in the
+// dependency of the package kind is qualified by, by import path or by the
+// name of a package it imports.
+func lookupStruct(kind string, p *packages.Package) (*types.Named, error) {
+	pkg, name := p.Types, kind
+	if i := strings.LastIndex(kind, "."); i >= 0 {
+		qual := kind[:i]
+		name = kind[i+1:]
+
+		pkg = importedPackage(p, qual)
+		if pkg == nil && !strings.Contains(qual, "/") {
+			for _, imp := range p.Types.Imports() {
+				if imp.Name() == qual {
+					pkg = imp
+					break
+				}
+			}
+		}
+		if pkg == nil {
+			return nil, fmt.Errorf("package %s not found among the dependencies of %s", qual, p.PkgPath)
+		}
+	}
+
+	var obj *types.TypeName
+	switch o := pkg.Scope().Lookup(name).(type) {
+	case *types.TypeName:
+		obj = o
+	case nil:
+		return nil, typeNotFound(name, pkg.Scope())
+	default:
+		return nil, fmt.Errorf("type not found: %s is a %s, not a type", name, objectKind(o))
+	}
+
+	named, ok := types.Unalias(obj.Type()).(*types.Named)
+	switch {
+	case pkg != p.Types && !obj.Exported():
+		return nil, fmt.Errorf("%s is not exported", name)
+	case !ok:
+		return nil, fmt.Errorf("%s is not a named type", name)
+	case named.TypeParams().Len() > named.TypeArgs().Len():
+		return nil, fmt.Errorf("%s is a generic type", name)
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return nil, fmt.Errorf("%s is not a struct type", name)
+	}
+
+	return named, nil
+}
+
+// generateConvertFunc builds the function converting a value of the source
+// type of the conversion to the destination type. The fields of both are
+// matched by name and deep copied, converting the nested structs field by
+// field, and the fields left unmatched are reported as warnings.
+func (g Generator) generateConvertFunc(p *packages.Package, conv conversion, convs []conversion, generating []object) (*ast.FuncDecl, error) {
+	sc := g.funcScope()
+	src, dst := ident(sc.declare("src")), ident(sc.declare("dst"))
+	from, to := g.typeExpr(conv.from, p.Name), g.typeExpr(conv.to, p.Name)
+
+	body := []ast.Stmt{varDecl(dst.Name, to, nil)}
+
+	c := converter{g: g, fn: conv.fn, convs: convs, generating: generating, sc: sc}
+	stmts, err := c.walk(dst, src, "", p.Name, conv.from, conv.to, 0)
+	if err != nil {
+		return nil, err
+	}
+	body = append(body, stmts...)
+	body = append(body, &ast.ReturnStmt{Results: []ast.Expr{dst}})
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %s converts %s to %s, deep copying the fields", conv.fn, types.ExprString(from), types.ExprString(to))},
+		}},
+		Name: ident(conv.fn),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{src}, Type: from}}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: to}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}, nil
+}
+
+// converter builds the body of a converter function.
+type converter struct {
+	g          Generator
+	fn         string
+	convs      []conversion
+	generating []object
+
+	// sc is the scope the locals of the current block are declared in.
+	sc *scope
+
+	// inlined are the pairs of struct types being converted field by field,
+	// which must not nest in themselves.
+	inlined [][2]types.Type
+}
+
+// walk returns the statements converting the value src of type from to dst
+// of type to. sel is the selector of the values, in the syntax of the skip
+// selectors.
+func (c converter) walk(dst, src ast.Expr, sel, x string, from, to types.Type, depth int) ([]ast.Stmt, error) {
+	g := c.g
+	from, to = types.Unalias(from), types.Unalias(to)
+
+	if types.Identical(from, to) {
+		return c.assignCopy(dst, src, x, to, depth), nil
+	}
+
+	if fn, ok := c.declared(from, to); ok && depth > 0 {
+		return []ast.Stmt{assign(dst, call(ident(fn), src))}, nil
+	}
+
+	depth++
+	switch t := to.Underlying().(type) {
+	case *types.Struct:
+		f, ok := from.Underlying().(*types.Struct)
+		if !ok {
+			break
+		}
+
+		for _, pair := range c.inlined {
+			if types.Identical(pair[0], from) && types.Identical(pair[1], to) {
+				return nil, fmt.Errorf("field %s: %s converts to %s recursively, add a conversion of its own",
+					sel, g.getElemType(from, x), g.getElemType(to, x))
+			}
+		}
+		c.inlined = append(c.inlined[:len(c.inlined):len(c.inlined)], [2]types.Type{from, to})
+
+		return c.walkFields(dst, src, sel, x, from, to, f, t, depth)
+	case *types.Pointer:
+		f, ok := from.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+
+		edst, esrc := c.deref(dst, src, f.Elem(), t.Elem())
+
+		c.sc = c.sc.inner()
+		stmts, err := c.walk(edst, esrc, sel, x, f.Elem(), t.Elem(), depth)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ast.Stmt{ifNotNil(src,
+			append([]ast.Stmt{assign(dst, call(ident("new"), g.typeExpr(t.Elem(), x)))}, stmts...)...,
+		)}, nil
+	case *types.Slice:
+		f, ok := from.Underlying().(*types.Slice)
+		if !ok {
+			break
+		}
+
+		c.sc = c.sc.inner()
+		stmts, err := c.walkElems(dst, src, sel, x, f.Elem(), t.Elem(), depth)
+		if err != nil {
+			return nil, err
+		}
+
+		return []ast.Stmt{ifNotNil(src,
+			append([]ast.Stmt{assign(dst, call(ident("make"), g.typeExpr(to, x), call(ident("len"), src)))}, stmts...)...,
+		)}, nil
+	case *types.Array:
+		f, ok := from.Underlying().(*types.Array)
+		if !ok || f.Len() != t.Len() {
+			break
+		}
+
+		return c.walkElems(dst, src, sel, x, f.Elem(), t.Elem(), depth)
+	case *types.Map:
+		f, ok := from.Underlying().(*types.Map)
+		if !ok {
+			break
+		}
+
+		esel := sel + "[k]"
+		// The loop is in the block of the nil check.
+		loopScope := c.sc.inner().inner()
+		key, val := loopScope.declare(indexed("k", depth)), loopScope.declare(indexed("v", depth))
+		c.sc = loopScope.inner()
+
+		kstmts, ckey, err := c.value(exprIdent(dst)+"_"+key, ident(key), esel, x, f.Key(), t.Key(), depth)
+		if err != nil {
+			return nil, err
+		}
+		vstmts, cval, err := c.value(exprIdent(dst)+"_"+val, ident(val), esel, x, f.Elem(), t.Elem(), depth)
+		if err != nil {
+			return nil, err
+		}
+
+		body := append(kstmts, vstmts...)
+		body = append(body, assign(&ast.IndexExpr{X: dst, Index: ckey}, cval))
+
+		return []ast.Stmt{ifNotNil(src,
+			assign(dst, call(ident("make"), g.typeExpr(to, x), call(ident("len"), src))),
+			&ast.RangeStmt{
+				Key:   ident(key),
+				Value: ident(val),
+				Tok:   token.DEFINE,
+				X:     src,
+				Body:  &ast.BlockStmt{List: body},
+			},
+		)}, nil
+	case *types.Basic:
+		if f, ok := from.Underlying().(*types.Basic); ok && f.Kind() == t.Kind() {
+			return []ast.Stmt{assign(dst, call(g.typeExpr(to, x), src))}, nil
+		}
+	}
+
+	c.warn("field %s: can't convert %s to %s", sel, g.getElemType(from, x), g.getElemType(to, x))
+	return nil, nil
+}
+
+// walkFields returns the statements converting the fields of the struct src
+// to the fields of the struct dst matching them.
+func (c converter) walkFields(dst, src ast.Expr, sel, x string, from, to types.Type, f, t *types.Struct, depth int) ([]ast.Stmt, error) {
+	srcFields, err := convertedFields(from, f, x)
+	if err != nil {
+		return nil, err
+	}
+	dstFields, err := convertedFields(to, t, x)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmts []ast.Stmt
+	matched := map[string]bool{}
+	for _, df := range dstFields {
+		fsel := strings.TrimPrefix(sel+"."+df.field.Name(), ".")
+
+		var sf *convertedField
+		for i := range srcFields {
+			if srcFields[i].key == df.key {
+				sf = &srcFields[i]
+				break
+			}
+		}
+		if sf == nil {
+			c.warn("destination field %s has no source", fsel)
+			continue
+		}
+		matched[sf.key] = true
+
+		fdst, fsrc := selector(dst, df.field.Name()), selector(src, sf.field.Name())
+		fstmts, err := c.walk(fdst, fsrc, fsel, x, sf.field.Type(), df.field.Type(), depth)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, fstmts...)
+	}
+
+	for _, sf := range srcFields {
+		if !matched[sf.key] {
+			c.warn("source field %s has no destination", strings.TrimPrefix(sel+"."+sf.field.Name(), "."))
+		}
+	}
+
+	return stmts, nil
+}
+
+// walkElems returns the loop converting the elements of the array or slice
+// src to the elements of dst.
+func (c converter) walkElems(dst, src ast.Expr, sel, x string, from, to types.Type, depth int) ([]ast.Stmt, error) {
+	loopScope := c.sc.inner()
+	idx := loopScope.declare(indexed("i", depth))
+	c.sc = loopScope.inner()
+
+	body, err := c.walk(index(dst, idx), index(src, idx), sel+"[i]", x, from, to, depth)
+	if err != nil {
+		return nil, err
+	}
+
+	return []ast.Stmt{&ast.RangeStmt{
+		Key:  ident(idx),
+		Tok:  token.DEFINE,
+		X:    src,
+		Body: &ast.BlockStmt{List: body},
+	}}, nil
+}
+
+// value returns the statements converting the map key or value src to a
+// variable named after name, along with the expression of the converted
+// value: src itself when it is assigned as is.
+func (c converter) value(name string, src *ast.Ident, sel, x string, from, to types.Type, depth int) ([]ast.Stmt, ast.Expr, error) {
+	v := c.sc.declare(name)
+
+	stmts, err := c.walk(ident(v), src, sel, x, from, to, depth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(stmts) == 1 {
+		if s, ok := stmts[0].(*ast.AssignStmt); ok && s.Tok == token.ASSIGN && types.ExprString(s.Lhs[0]) == v {
+			if types.ExprString(s.Rhs[0]) == src.Name {
+				return nil, src, nil
+			}
+
+			return []ast.Stmt{define(v, s.Rhs[0])}, ident(v), nil
+		}
+	}
+
+	return append([]ast.Stmt{varDecl(v, c.g.typeExpr(to, x), nil)}, stmts...), ident(v), nil
+}
+
+// deref returns the expressions of the values the pointers dst and src point
+// to, of types from and to.
+func (c converter) deref(dst, src ast.Expr, from, to types.Type) (ast.Expr, ast.Expr) {
+	_, fromStruct := from.Underlying().(*types.Struct)
+	_, toStruct := to.Underlying().(*types.Struct)
+	_, isDeclared := c.declared(from, to)
+	switch {
+	case isDeclared:
+		return &ast.StarExpr{X: dst}, &ast.StarExpr{X: src}
+	case fromStruct && toStruct && !types.Identical(from, to):
+		// Fields are selected through the pointers.
+		return dst, src
+	}
+
+	switch to.Underlying().(type) {
+	case *types.Struct, *types.Slice, *types.Array, *types.Map:
+		// The values may be selected from or indexed.
+		return &ast.ParenExpr{X: &ast.StarExpr{X: dst}}, &ast.ParenExpr{X: &ast.StarExpr{X: src}}
+	default:
+		return &ast.StarExpr{X: dst}, &ast.StarExpr{X: src}
+	}
+}
+
+// declared returns the function of the conversion from and to are the types
+// of, if any.
+func (c converter) declared(from, to types.Type) (string, bool) {
+	for _, conv := range c.convs {
+		if types.Identical(conv.from, from) && types.Identical(conv.to, to) {
+			return conv.fn, true
+		}
+	}
+
+	return "", false
+}
+
+// warn reports a field the converter leaves out.
+func (c converter) warn(format string, args ...any) {
+	c.g.warn("%s: %s", c.fn, fmt.Sprintf(format, args...))
+}
+
+// convertedField is a field of a struct converted from or to, with the name
+// it is matched by.
+type convertedField struct {
+	field *types.Var
+	key   string
+}
+
+// convertedFields returns the fields of the struct s of type m a converter
+// can access, keyed by the name of their convert tag or else by their own.
+// The fields tagged with "-" are left out.
+func convertedFields(m types.Type, s *types.Struct, x string) ([]convertedField, error) {
+	foreign := isForeign(m, x)
+
+	var fields []convertedField
+	for i := 0; i < s.NumFields(); i++ {
+		field := s.Field(i)
+		if foreign && !field.Exported() || field.Name() == "_" {
+			continue
+		}
+
+		key := field.Name()
+		if tag, ok := reflect.StructTag(s.Tag(i)).Lookup(convertTag); ok {
+			if tag == "-" {
+				continue
+			}
+
+			for _, opt := range strings.Split(tag, ",") {
+				if name, ok := strings.CutPrefix(opt, "name="); ok && name != "" {
+					key = name
+				}
+			}
+		}
+
+		for _, other := range fields {
+			if other.key == key {
+				return nil, fmt.Errorf("fields %s and %s both convert as %s", other.field.Name(), field.Name(), key)
+			}
+		}
+
+		fields = append(fields, convertedField{field: field, key: key})
+	}
+
+	return fields, nil
+}
+
+// assignCopy returns the statements assigning a deep copy of src to dst.
+func (c converter) assignCopy(dst, src ast.Expr, x string, m types.Type, depth int) []ast.Stmt {
+	// The depth is past the top level, so that deep copy methods are
+	// reused.
+	depth = max(depth, 1)
+
+	var root string
+	if len(c.generating) > 0 {
+		root = c.generating[0].Obj().Name()
+	}
+
+	cp := c.g.copyPlanner(x, nil, c.generating).plan("", "", m, depth)
+	e := emitter{g: c.g, x: x, root: root}
+	stmts := e.emit(src, dst, cp, c.sc, depth)
+	if len(stmts) > 0 && assignsWhole(cp) {
+		return stmts
+	}
+
+	return append([]ast.Stmt{assign(dst, src)}, stmts...)
+}
+
+// isForeign reports whether m is a named type of another package than x,
+// whose unexported fields are out of reach.
+func isForeign(m types.Type, x string) bool {
+	v, ok := m.(*types.Named)
+	return ok && v.Obj().Pkg() != nil && v.Obj().Pkg().Name() != x
+}
@@ -0,0 +1,93 @@
+package deepcopy
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConversion(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Conversion
+		wantErr string
+	}{
+		{spec: "domain.Order:api.Order", want: Conversion{From: "domain.Order", To: "api.Order"}},
+		{spec: "example.com/app/api.Order:Order=FromAPI", want: Conversion{From: "example.com/app/api.Order", To: "Order", Func: "FromAPI"}},
+		{spec: "domain.Order", wantErr: `invalid conversion "domain.Order", want From:To or From:To=Func`},
+		{spec: ":api.Order", wantErr: `invalid conversion ":api.Order", want From:To or From:To=Func`},
+		{spec: "A:B=1st", wantErr: `invalid conversion "A:B=1st": "1st" is not a function name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseConversion(tt.spec)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.spec, got.String())
+		})
+	}
+}
+
+func TestGenerateConvertErrors(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata/convert")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		convs   []Conversion
+		wantErr string
+	}{
+		{
+			name:    "type not found",
+			convs:   []Conversion{{From: "Lst", To: "OtherList"}},
+			wantErr: `locating type "Lst" of conversion Lst:OtherList: type not found, did you mean ` + "`List`?",
+		},
+		{
+			name:    "package not found",
+			convs:   []Conversion{{From: "domain.List", To: "OtherList"}},
+			wantErr: `locating type "domain.List" of conversion domain.List:OtherList: package domain not found among the dependencies of github.com/globusdigital/deep-copy/testdata/convert`,
+		},
+		{
+			name:    "not a struct",
+			convs:   []Conversion{{From: "Status", To: "OtherList"}},
+			wantErr: `locating type "Status" of conversion Status:OtherList: Status is not a struct type`,
+		},
+		{
+			name:    "same function",
+			convs:   []Conversion{{From: "List", To: "OtherList"}, {From: "Node", To: "OtherList"}},
+			wantErr: "conversions List:OtherList and Node:OtherList both generate ConvertOtherList, name one with =Func",
+		},
+		{
+			name:    "recursive inlined type",
+			convs:   []Conversion{{From: "List", To: "OtherList"}},
+			wantErr: "generating converter ConvertOtherList: field Head.Next: Node converts to OtherNode recursively, add a conversion of its own",
+		},
+		{
+			name:  "recursive declared type",
+			convs: []Conversion{{From: "List", To: "OtherList"}, {From: "Node", To: "OtherNode"}},
+		},
+		{
+			name:    "duplicate names",
+			convs:   []Conversion{{From: "Tagged", To: "List"}},
+			wantErr: "generating converter ConvertList: fields A and C both convert as C",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := NewGenerator(WithConversions(tt.convs)).Generate(&buf, nil, pkgs[0])
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,327 @@
+// Package copycheck defines an Analyzer that reports hand-written deep copy
+// methods which leave pointer, slice, map or interface fields of their
+// receiver shared with the original.
+//
+// A deep copy method takes no parameters and returns a value of its receiver
+// type, or a pointer to it, following the same rules the generator uses to
+// reuse existing methods. A field is considered copied if the method body
+// assigns it anything but a field of the receiver, takes its address, or sets
+// it in a composite literal. Fields of nested struct values are tracked by
+// their path from the copy, as in "A.P". Generated files are ignored.
+package copycheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/globusdigital/deep-copy/deepcopy"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// Analyzer reports fields that deep copy methods never reassign.
+var Analyzer = &analysis.Analyzer{
+	Name:     "copycheck",
+	Doc:      "report pointer, slice, map and interface fields that deep copy methods leave shared",
+	URL:      "https://github.com/globusdigital/deep-copy",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var methodName string
+
+func init() {
+	Analyzer.Flags.StringVar(&methodName, "method", "DeepCopy", "deep copy method name")
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	generated := map[*token.File]bool{}
+	for _, f := range pass.Files {
+		if ast.IsGenerated(f) {
+			generated[pass.Fset.File(f.Pos())] = true
+		}
+	}
+
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		fn := n.(*ast.FuncDecl)
+		if fn.Recv == nil || fn.Body == nil || generated[pass.Fset.File(fn.Pos())] {
+			return
+		}
+
+		m, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+		if !ok {
+			return
+		}
+		if ok, _ := deepcopy.IsDeepCopyMethod(m, methodName); !ok {
+			return
+		}
+
+		recv := m.Type().(*types.Signature).Recv().Type()
+		if p, ok := recv.(*types.Pointer); ok {
+			recv = p.Elem()
+		}
+		named, ok := types.Unalias(recv).(*types.Named)
+		if !ok {
+			return
+		}
+		st, ok := named.Underlying().(*types.Struct)
+		if !ok {
+			return
+		}
+
+		var recvObj types.Object
+		if names := fn.Recv.List[0].Names; len(names) > 0 {
+			recvObj = pass.TypesInfo.Defs[names[0]]
+		}
+
+		copied := copiedPaths(pass.TypesInfo, fn.Body, recvObj, named)
+		for _, f := range uncopiedFields(pass.Pkg, st, nil, copied, map[*types.Struct]bool{}) {
+			pass.Report(analysis.Diagnostic{
+				Pos:     fn.Name.Pos(),
+				Message: m.Name() + " of " + named.Obj().Name() + " does not copy " + kindOf(f.field.Type()) + " field " + strings.Join(f.path, "."),
+				Related: []analysis.RelatedInformation{{Pos: f.field.Pos(), Message: "field declared here"}},
+			})
+		}
+	})
+
+	return nil, nil
+}
+
+// copies records the field paths of the values of the receiver type a deep
+// copy method copies, as in "Inner.P".
+type copies struct {
+	info *types.Info
+	recv types.Object
+	typ  types.Type
+	// paths are the copied field paths. A path covers the fields nested in
+	// it.
+	paths map[string]bool
+}
+
+// copiedPaths returns the field paths of the values of the receiver type the
+// body assigns from anything but the receiver, takes the address of, or sets
+// in composite literals.
+func copiedPaths(info *types.Info, body *ast.BlockStmt, recv types.Object, typ types.Type) map[string]bool {
+	c := copies{info: info, recv: recv, typ: typ, paths: map[string]bool{}}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				// The values of a multi-value call are no shallow copies.
+				for _, lhs := range n.Lhs {
+					c.assign(lhs, nil)
+				}
+				break
+			}
+			for i, lhs := range n.Lhs {
+				c.assign(lhs, n.Rhs[i])
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				if path, ok := c.path(n.X); ok {
+					c.mark(path)
+				}
+			}
+		case *ast.CompositeLit:
+			if tv, ok := info.Types[n]; ok && types.Identical(tv.Type, typ) {
+				c.literal(nil, n)
+			}
+		}
+		return true
+	})
+
+	return c.paths
+}
+
+// assign records the assignment of rhs, which is nil for the values of a
+// call, to lhs.
+func (c copies) assign(lhs, rhs ast.Expr) {
+	path, ok := c.path(lhs)
+	if !ok {
+		return
+	}
+	if rhs == nil {
+		c.mark(path)
+		return
+	}
+
+	c.value(path, rhs)
+}
+
+// value records the copy of the value e into the field at path.
+func (c copies) value(path []string, e ast.Expr) {
+	e = ast.Unparen(e)
+
+	// The fields of a struct value are copied one by one.
+	if lit, ok := e.(*ast.CompositeLit); ok {
+		if _, ok := c.info.TypeOf(lit).Underlying().(*types.Struct); ok {
+			c.literal(path, lit)
+			return
+		}
+	}
+
+	// The fields of the receiver are shared with the original.
+	if root, _, ok := c.fieldPath(e); ok && root == c.recv {
+		return
+	}
+
+	c.mark(path)
+}
+
+// literal records the fields the struct composite literal at path sets.
+func (c copies) literal(path []string, lit *ast.CompositeLit) {
+	st, ok := c.info.TypeOf(lit).Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+
+	for i, elt := range lit.Elts {
+		var (
+			f     *types.Var
+			value = elt
+		)
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			key, _ := kv.Key.(*ast.Ident)
+			if key == nil {
+				continue
+			}
+			f, _ = c.info.Uses[key].(*types.Var)
+			value = kv.Value
+		} else if i < st.NumFields() {
+			f = st.Field(i)
+		}
+		if f == nil {
+			continue
+		}
+
+		c.value(append(path[:len(path):len(path)], f.Name()), value)
+	}
+}
+
+func (c copies) mark(path []string) {
+	if len(path) > 0 {
+		c.paths[strings.Join(path, ".")] = true
+	}
+}
+
+// path returns the field path of e, if it selects a field of a value of the
+// receiver type.
+func (c copies) path(e ast.Expr) ([]string, bool) {
+	root, path, ok := c.fieldPath(e)
+	if !ok || root == nil {
+		return nil, false
+	}
+
+	t := root.Type()
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if !types.Identical(t, c.typ) {
+		return nil, false
+	}
+
+	return path, true
+}
+
+// fieldPath returns the variable e selects fields of, along with the names
+// of the fields, embedded ones included.
+func (c copies) fieldPath(e ast.Expr) (types.Object, []string, bool) {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		obj, ok := c.info.ObjectOf(e).(*types.Var)
+		return obj, nil, ok
+	case *ast.StarExpr:
+		return c.fieldPath(e.X)
+	case *ast.SelectorExpr:
+		s := c.info.Selections[e]
+		if s == nil || s.Kind() != types.FieldVal {
+			return nil, nil, false
+		}
+
+		root, path, ok := c.fieldPath(e.X)
+		if !ok {
+			return nil, nil, false
+		}
+
+		t := s.Recv()
+		for _, i := range s.Index() {
+			if p, ok := t.Underlying().(*types.Pointer); ok {
+				t = p.Elem()
+			}
+			f := t.Underlying().(*types.Struct).Field(i)
+			path = append(path, f.Name())
+			t = f.Type()
+		}
+		return root, path, true
+	default:
+		return nil, nil, false
+	}
+}
+
+type uncopied struct {
+	path  []string
+	field *types.Var
+}
+
+// uncopiedFields returns the fields of st, and of its nested struct values,
+// which share memory with the original unless their path, or the path of a
+// struct holding them, is copied.
+func uncopiedFields(pkg *types.Package, st *types.Struct, path []string, copied map[string]bool, seen map[*types.Struct]bool) []uncopied {
+	if seen[st] {
+		return nil
+	}
+	seen[st] = true
+	defer delete(seen, st)
+
+	var fields []uncopied
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if f.Name() == "_" || !f.Exported() && f.Pkg() != pkg {
+			continue
+		}
+
+		fpath := append(path[:len(path):len(path)], f.Name())
+		if covered(fpath, copied) {
+			continue
+		}
+
+		switch u := f.Type().Underlying().(type) {
+		case *types.Pointer, *types.Slice, *types.Map, *types.Interface:
+			fields = append(fields, uncopied{path: fpath, field: f})
+		case *types.Struct:
+			fields = append(fields, uncopiedFields(pkg, u, fpath, copied, seen)...)
+		}
+	}
+
+	return fields
+}
+
+// covered reports whether the path, or a path holding it, is copied.
+func covered(path []string, copied map[string]bool) bool {
+	for i := 1; i <= len(path); i++ {
+		if copied[strings.Join(path[:i], ".")] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func kindOf(t types.Type) string {
+	switch t.Underlying().(type) {
+	case *types.Pointer:
+		return "pointer"
+	case *types.Slice:
+		return "slice"
+	case *types.Map:
+		return "map"
+	default:
+		return "interface"
+	}
+}
@@ -0,0 +1,12 @@
+package copycheck_test
+
+import (
+	"testing"
+
+	"github.com/globusdigital/deep-copy/deepcopy/copycheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), copycheck.Analyzer, "a")
+}
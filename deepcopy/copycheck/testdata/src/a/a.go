@@ -0,0 +1,102 @@
+package a
+
+type Inner struct {
+	P *int
+	N int
+}
+
+type Complete struct {
+	P     *int
+	S     []string
+	M     map[string]int
+	I     any
+	Inner Inner
+	ch    chan int
+}
+
+func (o Complete) DeepCopy() Complete {
+	cp := o
+	if o.P != nil {
+		cp.P = new(int)
+		*cp.P = *o.P
+	}
+	cp.S = append([]string(nil), o.S...)
+	cp.M = make(map[string]int, len(o.M))
+	for k, v := range o.M {
+		cp.M[k] = v
+	}
+	cp.I = copyAny(o.I)
+	copyInner(&cp.Inner.P)
+	return cp
+}
+
+func copyInner(p **int) {}
+
+func copyAny(v any) any { return v }
+
+type Shallow struct {
+	P *int
+	I any
+}
+
+func (o Shallow) DeepCopy() Shallow { // want `DeepCopy of Shallow does not copy interface field I`
+	cp := o
+	cp.P = new(int)
+	cp.I = o.I
+	return cp
+}
+
+type Pair struct {
+	A, B Inner
+}
+
+func (o *Pair) DeepCopy() *Pair { // want `DeepCopy of Pair does not copy pointer field B.P`
+	cp := *o
+	copyInner(&cp.A.P)
+	return &cp
+}
+
+type Incomplete struct {
+	P     *int
+	S     []string
+	Inner Inner
+	N     int
+}
+
+func (o *Incomplete) DeepCopy() *Incomplete { // want `DeepCopy of Incomplete does not copy slice field S` `DeepCopy of Incomplete does not copy pointer field Inner.P`
+	cp := *o
+	if o.P != nil {
+		cp.P = new(int)
+	}
+	return &cp
+}
+
+type Literal struct {
+	A *int
+	B []int
+}
+
+func (o *Literal) DeepCopy() *Literal { // want `DeepCopy of Literal does not copy slice field B`
+	return &Literal{A: new(int)}
+}
+
+type Positional struct {
+	A *int
+	B []int
+}
+
+func (o Positional) DeepCopy() Positional {
+	return Positional{new(int), nil}
+}
+
+type NotACopy struct {
+	P *int
+}
+
+func (o NotACopy) DeepCopy() int {
+	return 0
+}
+
+func (o NotACopy) Clone() NotACopy {
+	return o
+}
@@ -0,0 +1,385 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// diffMethodName is the name of the generated diff methods, and of the
+// existing ones they call.
+const diffMethodName = "Diff"
+
+// generateDiffFunc builds the Diff method of the type, reporting the changes
+// of the members its deep copy method copies, and ignoring the skipped ones.
+// The changes are Change values of this package, which the generated code
+// imports.
+func (g Generator) generateDiffFunc(p *packages.Package, obj object, skips skips, generating []object) *ast.FuncDecl {
+	kind := obj.Obj().Name()
+	var recv ast.Expr = ident(kind)
+	var ptr string
+	if g.isPtrRecv {
+		ptr = "*"
+		recv = &ast.StarExpr{X: recv}
+	}
+
+	sc := g.funcScope()
+	source, other, changes := ident(sc.declare("o")), ident(sc.declare("other")), ident(sc.declare("changes"))
+	pkg := ident(g.importPath(changePath))
+	result := &ast.ArrayType{Elt: selector(pkg, "Change")}
+
+	d := differ{g: g, changes: changes, pkg: pkg}
+	body := []ast.Stmt{varDecl(changes.Name, result, nil)}
+	ret := &ast.ReturnStmt{Results: []ast.Expr{changes}}
+
+	var a, b ast.Expr = source, other
+	if g.isPtrRecv {
+		body = append(body, &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.BinaryExpr{X: source, Op: token.EQL, Y: ident("nil")},
+				Op: token.LOR,
+				Y:  &ast.BinaryExpr{X: other, Op: token.EQL, Y: ident("nil")},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.IfStmt{
+					Cond: &ast.BinaryExpr{X: source, Op: token.NEQ, Y: other},
+					Body: &ast.BlockStmt{List: []ast.Stmt{d.change("Modified", "", nil, source, other)}},
+				},
+				ret,
+			}},
+		})
+
+		a, b = derefRecv(source, other, obj)
+	}
+
+	body = append(body, d.diff(a, b, nil, g.diffPlanner(p.Name, skips, generating).plan("", "", obj, 0), sc, 0)...)
+	body = append(body, ret)
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %s reports the changes from %s%s to %s", diffMethodName, ptr, kind, other.Name)},
+		}},
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{source}, Type: recv}}},
+		Name: ident(diffMethodName),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{other}, Type: recv}}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: result}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
+
+// diffPlanner returns the planner of the diff methods, which reuse the diff
+// methods of the members. Types without a diff method but with an existing
+// equality method are compared by it as a whole.
+func (g Generator) diffPlanner(x string, skips skips, generating []object) planner {
+	return planner{g: g, x: x, skips: skips, find: func(v methoder) (string, bool, bool) {
+		if hasMethod, isPointer := g.hasDiff(v, generating); hasMethod {
+			return diffMethodName, isPointer, true
+		}
+
+		// The generated types have a diff method, so only existing
+		// equality methods are looked for.
+		hasMethod, isPointer := g.hasEqual(v, nil)
+		return equalMethodName, isPointer, hasMethod
+	}}
+}
+
+// differ builds the statements of diff methods from their plans, appending
+// to the changes variable the Change values of the package imported as pkg.
+type differ struct {
+	g       Generator
+	changes *ast.Ident
+	pkg     *ast.Ident
+}
+
+// change returns the append of a change of the given kind to the member at
+// sel, whose [i] and [k] stand for the keys. old and new are left out when
+// nil.
+func (d differ) change(kind, sel string, keys []ast.Expr, old, new ast.Expr) ast.Stmt {
+	lit := &ast.CompositeLit{Type: selector(d.pkg, "Change")}
+	field := func(name string, value ast.Expr) {
+		lit.Elts = append(lit.Elts, &ast.KeyValueExpr{Key: ident(name), Value: value})
+	}
+
+	field("Selector", &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(sel)})
+	if len(keys) > 0 {
+		field("Keys", keysLit(keys))
+	}
+	field("Kind", selector(d.pkg, kind))
+	if old != nil {
+		field("Old", old)
+	}
+	if new != nil {
+		field("New", new)
+	}
+
+	return assign(d.changes, call(ident("append"), d.changes, lit))
+}
+
+// modified returns the statement appending the modification of a into b if
+// cond holds.
+func (d differ) modified(cond ast.Expr, sel string, keys []ast.Expr, a, b ast.Expr) *ast.IfStmt {
+	return &ast.IfStmt{Cond: cond, Body: &ast.BlockStmt{List: []ast.Stmt{d.change("Modified", sel, keys, a, b)}}}
+}
+
+// diff returns the statements comparing the values a and b following the
+// plan, appending their changes. keys are the loop variables the [i] and [k]
+// of the selectors of the plan stand for. The locals are declared in sc.
+// depth follows the one of the plan.
+func (d differ) diff(a, b ast.Expr, keys []ast.Expr, plan Plan, sc *scope, depth int) []ast.Stmt {
+	g := d.g
+	sel := plan.At().Selector
+
+	switch n := plan.(type) {
+	case *Skip:
+		// Past the max depth, members are compared by reflection.
+		if n.Reason == PastMaxDepth {
+			return []ast.Stmt{d.modified(notDeepEqual(g, a, b), sel, keys, a, b)}
+		}
+		return nil
+	case *ShallowCopy:
+		if _, ok := n.Type.Underlying().(*types.Signature); ok {
+			// As in Equal methods, only the nilness of functions is
+			// compared.
+			return []ast.Stmt{d.modified(nilDiffers(a, b), sel, keys, a, b)}
+		}
+		return []ast.Stmt{d.modified(notEqual(g, a, b, n.Type), sel, keys, a, b)}
+	case *ChanCopy:
+		// As in Equal methods, only the nilness of channels is compared.
+		return []ast.Stmt{d.modified(nilDiffers(a, b), sel, keys, a, b)}
+	case *MethodReuse:
+		reuse := d.reuse(a, b, sel, keys, n)
+		if !n.Pointer {
+			return []ast.Stmt{reuse}
+		}
+
+		s := d.modified(nilDiffers(a, b), sel, keys, a, b)
+		s.Else = ifNotNil(a, reuse)
+		return []ast.Stmt{s}
+	}
+
+	depth++
+	switch n := plan.(type) {
+	case *StructCopy:
+		// As in Equal methods, opaque structs are compared as a whole.
+		if opaque(n) {
+			return []ast.Stmt{d.modified(notEqual(g, a, b, n.Type), sel, keys, a, b)}
+		}
+
+		var stmts []ast.Stmt
+		for _, f := range n.Fields {
+			stmts = append(stmts, d.diff(selector(a, f.Name), selector(b, f.Name), keys, f.Copy, sc, depth)...)
+		}
+		return stmts
+	case *ArrayCopy:
+		loopScope := sc.inner()
+		idx := loopScope.declare(indexed("i", depth))
+
+		body := d.diff(index(a, idx), index(b, idx), withKey(keys, idx), n.Elem, loopScope.inner(), depth)
+		if len(body) == 0 {
+			return nil
+		}
+
+		return []ast.Stmt{&ast.RangeStmt{Key: ident(idx), Tok: token.DEFINE, X: a, Body: &ast.BlockStmt{List: body}}}
+	case *SliceCopy:
+		stmts := d.nilOrEmpty(a, b, sel, keys)
+
+		loopScope := sc.inner()
+		idx := loopScope.declare(indexed("i", depth))
+		esel, ekeys := n.Elem.At().Selector, withKey(keys, idx)
+		ea, eb := index(a, idx), index(b, idx)
+
+		body := []ast.Stmt{&ast.IfStmt{
+			Cond: &ast.BinaryExpr{X: ident(idx), Op: token.GEQ, Y: call(ident("len"), b)},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				d.change("Removed", esel, ekeys, ea, nil),
+				&ast.BranchStmt{Tok: token.CONTINUE},
+			}},
+		}}
+		body = append(body, d.diff(ea, eb, ekeys, n.Elem, loopScope.inner(), depth)...)
+
+		return append(stmts,
+			&ast.RangeStmt{Key: ident(idx), Tok: token.DEFINE, X: a, Body: &ast.BlockStmt{List: body}},
+			&ast.ForStmt{
+				Init: define(idx, call(ident("len"), a)),
+				Cond: &ast.BinaryExpr{X: ident(idx), Op: token.LSS, Y: call(ident("len"), b)},
+				Post: &ast.IncDecStmt{X: ident(idx), Tok: token.INC},
+				Body: &ast.BlockStmt{List: []ast.Stmt{d.change("Added", esel, ekeys, nil, eb)}},
+			},
+		)
+	case *MapCopy:
+		// The changes of the entries are sorted by their keys, as maps are
+		// ranged over in no particular order.
+		start := sc.declare("n_" + exprIdent(a))
+		stmts := append(d.nilOrEmpty(a, b, sel, keys), define(start, call(ident("len"), d.changes)))
+
+		loopScope := sc.inner()
+		key, va, vb := loopScope.declare(indexed("k", depth)), loopScope.declare(indexed("va", depth)), loopScope.declare(indexed("vb", depth))
+		esel, ekeys := n.Elem.At().Selector, withKey(keys, key)
+
+		bodyScope := loopScope.inner()
+		ok := bodyScope.declare("ok")
+
+		eb := d.diff(ident(va), ident(vb), ekeys, n.Elem, bodyScope, depth)
+
+		removed := d.change("Removed", esel, ekeys, ident(va), nil)
+		var body []ast.Stmt
+		if len(eb) == 0 {
+			body = []ast.Stmt{&ast.IfStmt{
+				Init: lookup(ident("_"), ident(ok), b, key),
+				Cond: &ast.UnaryExpr{Op: token.NOT, X: ident(ok)},
+				Body: &ast.BlockStmt{List: []ast.Stmt{removed}},
+			}}
+		} else {
+			body = []ast.Stmt{
+				lookup(ident(vb), ident(ok), b, key),
+				&ast.IfStmt{
+					Cond: &ast.UnaryExpr{Op: token.NOT, X: ident(ok)},
+					Body: &ast.BlockStmt{List: []ast.Stmt{removed, &ast.BranchStmt{Tok: token.CONTINUE}}},
+				},
+			}
+			body = append(body, eb...)
+		}
+
+		added := &ast.IfStmt{
+			Init: lookup(ident("_"), ident(ok), a, key),
+			Cond: &ast.UnaryExpr{Op: token.NOT, X: ident(ok)},
+			Body: &ast.BlockStmt{List: []ast.Stmt{d.change("Added", esel, ekeys, nil, ident(vb))}},
+		}
+
+		return append(stmts,
+			&ast.RangeStmt{Key: ident(key), Value: ident(va), Tok: token.DEFINE, X: a, Body: &ast.BlockStmt{List: body}},
+			&ast.RangeStmt{Key: ident(key), Value: ident(vb), Tok: token.DEFINE, X: b, Body: &ast.BlockStmt{List: []ast.Stmt{added}}},
+			&ast.ExprStmt{X: call(selector(d.pkg, "SortChanges"), &ast.SliceExpr{X: d.changes, Low: ident(start)})},
+		)
+	case *PointerCopy:
+		s := d.modified(nilDiffers(a, b), sel, keys, a, b)
+
+		ea, eb := derefCompared(a, b, n.Elem)
+		if body := d.diff(ea, eb, keys, n.Elem, sc.inner(), depth); len(body) > 0 {
+			s.Else = ifNotNil(a, body...)
+		}
+		return []ast.Stmt{s}
+	default:
+		return nil
+	}
+}
+
+// nilOrEmpty returns the statements appending the change of the slices or
+// maps a and b when one is nil and the other empty, unless they are
+// considered equal.
+func (d differ) nilOrEmpty(a, b ast.Expr, sel string, keys []ast.Expr) []ast.Stmt {
+	if d.g.nilEqualsEmpty {
+		return nil
+	}
+
+	empty := func(v ast.Expr) ast.Expr {
+		return &ast.BinaryExpr{X: call(ident("len"), v), Op: token.EQL, Y: &ast.BasicLit{Kind: token.INT, Value: "0"}}
+	}
+	cond := &ast.BinaryExpr{
+		X:  &ast.BinaryExpr{X: empty(a), Op: token.LAND, Y: empty(b)},
+		Op: token.LAND,
+		Y:  nilDiffers(a, b),
+	}
+
+	return []ast.Stmt{d.modified(cond, sel, keys, a, b)}
+}
+
+// reuse returns the call to the method of the plan, nesting the changes a
+// diff method returns, or appending the modification of the values an
+// equality method reports unequal.
+func (d differ) reuse(a, b ast.Expr, sel string, keys []ast.Expr, n *MethodReuse) ast.Stmt {
+	result := call(selector(a, n.Method), methodArg(b, n))
+	if n.Method == equalMethodName {
+		return d.modified(&ast.UnaryExpr{Op: token.NOT, X: result}, sel, keys, a, b)
+	}
+
+	if sel != "" || len(keys) > 0 {
+		var keysArg ast.Expr = ident("nil")
+		if len(keys) > 0 {
+			keysArg = keysLit(keys)
+		}
+		result = call(selector(d.pkg, "NestChanges"), &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(sel)}, keysArg, result)
+	}
+
+	return assign(d.changes, &ast.CallExpr{Fun: ident("append"), Args: []ast.Expr{d.changes, result}, Ellipsis: 1})
+}
+
+// keysLit returns the []any literal of the keys.
+func keysLit(keys []ast.Expr) ast.Expr {
+	return &ast.CompositeLit{Type: &ast.ArrayType{Elt: ident("any")}, Elts: keys}
+}
+
+// withKey returns the keys, followed by the loop variable key.
+func withKey(keys []ast.Expr, key string) []ast.Expr {
+	return append(keys[:len(keys):len(keys)], ident(key))
+}
+
+// hasDiff reports whether the type has a diff method, generated or existing,
+// and whether it takes a pointer.
+func (g Generator) hasDiff(v methoder, generating []object) (hasMethod, isPointer bool) {
+	if types.IsInterface(v) {
+		return false, false
+	}
+
+	for _, t := range generating {
+		if types.Identical(v, t) {
+			return true, g.isPtrRecv
+		}
+	}
+
+	for i := 0; i < v.NumMethods(); i++ {
+		if m := v.Method(i); m.Name() == diffMethodName {
+			return IsDiffMethod(m, diffMethodName)
+		}
+	}
+
+	return false, false
+}
+
+// IsDiffMethod reports whether m is a diff method with the given name: it
+// takes a single value of its receiver type, or a pointer to it, and returns
+// a []Change. isPointer reports whether the parameter is a pointer.
+func IsDiffMethod(m *types.Func, name string) (ok, isPointer bool) {
+	if m.Name() != name {
+		return false, false
+	}
+
+	sig, _ := m.Type().(*types.Signature)
+	if sig == nil || sig.Recv() == nil || sig.Params().Len() != 1 || sig.Results().Len() != 1 {
+		return false, false
+	}
+
+	res, _ := sig.Results().At(0).Type().(*types.Slice)
+	if res == nil {
+		return false, false
+	}
+	if change, ok := types.Unalias(res.Elem()).(*types.Named); !ok || change.Obj().Pkg() == nil ||
+		change.Obj().Pkg().Path() != changePath || change.Obj().Name() != "Change" {
+		return false, false
+	}
+
+	paramType, paramPointer := reducePointer(sig.Params().At(0).Type())
+	recvType, _ := reducePointer(sig.Recv().Type())
+
+	if !types.Identical(paramType, recvType) {
+		return false, false
+	}
+
+	return true, paramPointer
+}
+
+// indexed returns the name of a loop variable at depth, suffixed by the depth
+// below the top level so that nested loops don't shadow each other.
+func indexed(name string, depth int) string {
+	if depth > 1 {
+		name += strconv.Itoa(depth)
+	}
+
+	return name
+}
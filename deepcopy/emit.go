@@ -0,0 +1,222 @@
+package deepcopy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// sourceError returns the first syntax error of err, positioned in src, whose
+// lines are offset by the given number in the source that was parsed, along
+// with the offending line.
+func sourceError(err error, src []byte, offset int) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) || len(list) == 0 {
+		return err
+	}
+
+	pos := list[0].Pos
+	line := pos.Line - offset
+	lines := strings.Split(string(src), "\n")
+	if line < 1 || line > len(lines) {
+		return fmt.Errorf("line %d:%d: %s", line, pos.Column, list[0].Msg)
+	}
+
+	return fmt.Errorf("line %d:%d: %s, in %q", line, pos.Column, list[0].Msg, strings.TrimSpace(lines[line-1]))
+}
+
+// printDecl writes the declaration, preceded by its doc comment. Built syntax
+// has no positions to place comments by, so the doc comment is written ahead
+// of the declaration.
+func printDecl(w io.Writer, d ast.Decl) error {
+	node := d
+	if fn, ok := node.(*ast.FuncDecl); ok && fn.Doc != nil {
+		for _, c := range fn.Doc.List {
+			fmt.Fprintln(w, c.Text)
+		}
+
+		undocumented := *fn
+		undocumented.Doc = nil
+		node = &undocumented
+	}
+
+	if err := format.Node(w, token.NewFileSet(), node); err != nil {
+		return fmt.Errorf("%s: %w", declName(d), err)
+	}
+
+	return nil
+}
+
+// declName names the declaration in errors.
+func declName(d ast.Decl) string {
+	fn, ok := d.(*ast.FuncDecl)
+	if !ok {
+		return "declaration"
+	}
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return "function " + fn.Name.Name
+	}
+
+	var b bytes.Buffer
+	_ = format.Node(&b, token.NewFileSet(), fn.Recv.List[0].Type)
+
+	return fmt.Sprintf("method %s of %s", fn.Name.Name, strings.TrimPrefix(b.String(), "*"))
+}
+
+// importDecl returns the declaration of the imports of the file, sorted by
+// path.
+func (g Generator) importDecl() *ast.GenDecl {
+	names := make([]string, 0, len(g.imports))
+	for name := range g.imports {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(g.imports[a], g.imports[b])
+	})
+
+	// A valid left parenthesis groups the imports.
+	d := &ast.GenDecl{Tok: token.IMPORT, Lparen: 1}
+	for _, name := range names {
+		path := g.imports[name]
+		spec := &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)}}
+		if !strings.HasSuffix(path, name) {
+			spec.Name = ast.NewIdent(name)
+		}
+		d.Specs = append(d.Specs, spec)
+	}
+
+	return d
+}
+
+// scope allocates the identifiers declared by the generated code, so that
+// they neither shadow nor collide with the ones of the enclosing scopes, of
+// the package or of its imports.
+type scope struct {
+	g     Generator
+	outer *scope
+	names map[string]bool
+}
+
+// funcScope returns the scope of a generated function.
+func (g Generator) funcScope() *scope {
+	return &scope{g: g, names: map[string]bool{}}
+}
+
+// inner returns a scope nested in s, as opened by a block.
+func (s *scope) inner() *scope {
+	return &scope{g: s.g, outer: s, names: map[string]bool{}}
+}
+
+// declare declares name in the scope, suffixed if needed so that it is
+// free, returning it.
+func (s *scope) declare(name string) string {
+	name = s.g.local(name)
+	for s.declared(name) {
+		name = s.g.local(name + "_")
+	}
+	s.names[name] = true
+
+	return name
+}
+
+// declared reports whether name is declared in the scope or an enclosing one.
+func (s *scope) declared(name string) bool {
+	for ; s != nil; s = s.outer {
+		if s.names[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// exprIdent derives an identifier from the selectors and indexes of e, as
+// cp_Map_i2 from cp.Map[i2].
+func exprIdent(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return exprIdent(e.X) + "_" + e.Sel.Name
+	case *ast.IndexExpr:
+		return exprIdent(e.X) + "_" + exprIdent(e.Index)
+	case *ast.StarExpr:
+		return exprIdent(e.X)
+	case *ast.ParenExpr:
+		return exprIdent(e.X)
+	default:
+		return ""
+	}
+}
+
+// typeExpr returns the syntax of the type, as referred to from the package
+// named x.
+func (g Generator) typeExpr(t types.Type, x string) ast.Expr {
+	kind := g.getElemType(t, x)
+
+	e, err := parser.ParseExpr(kind)
+	if err != nil {
+		// Printed as BadExpr, failing to parse the generated file at the
+		// offending declaration.
+		return &ast.BadExpr{}
+	}
+
+	return e
+}
+
+func ident(name string) *ast.Ident {
+	return ast.NewIdent(name)
+}
+
+func selector(x ast.Expr, name string) *ast.SelectorExpr {
+	return &ast.SelectorExpr{X: x, Sel: ident(name)}
+}
+
+func index(x ast.Expr, i string) *ast.IndexExpr {
+	return &ast.IndexExpr{X: x, Index: ident(i)}
+}
+
+func call(fun ast.Expr, args ...ast.Expr) *ast.CallExpr {
+	return &ast.CallExpr{Fun: fun, Args: args}
+}
+
+func assign(lhs, rhs ast.Expr) *ast.AssignStmt {
+	return &ast.AssignStmt{Lhs: []ast.Expr{lhs}, Tok: token.ASSIGN, Rhs: []ast.Expr{rhs}}
+}
+
+func define(name string, rhs ast.Expr) *ast.AssignStmt {
+	return &ast.AssignStmt{Lhs: []ast.Expr{ident(name)}, Tok: token.DEFINE, Rhs: []ast.Expr{rhs}}
+}
+
+func varDecl(name string, typ, value ast.Expr) *ast.DeclStmt {
+	spec := &ast.ValueSpec{Names: []*ast.Ident{ident(name)}, Type: typ}
+	if value != nil {
+		spec.Values = []ast.Expr{value}
+	}
+
+	return &ast.DeclStmt{Decl: &ast.GenDecl{Tok: token.VAR, Specs: []ast.Spec{spec}}}
+}
+
+func ifNotNil(x ast.Expr, body ...ast.Stmt) *ast.IfStmt {
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: x, Op: token.NEQ, Y: ident("nil")},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
+
+func ifNil(x ast.Expr, body ...ast.Stmt) *ast.IfStmt {
+	return &ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: x, Op: token.EQL, Y: ident("nil")},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
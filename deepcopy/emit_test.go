@@ -0,0 +1,69 @@
+package deepcopy
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeDeclare(t *testing.T) {
+	pkg := types.NewPackage("example.com/p", "p")
+	pkg.Scope().Insert(types.NewVar(0, pkg, "retV", types.Typ[types.Int]))
+
+	g := NewGenerator()
+	g.scope = pkg.Scope()
+	g.imports = map[string]string{"i2": "example.com/i2"}
+
+	fn := g.funcScope()
+	assert.Equal(t, "o", fn.declare("o"))
+	assert.Equal(t, "o_", fn.declare("o"), "collides with the same scope")
+
+	block := fn.inner()
+	assert.Equal(t, "o__", block.declare("o"), "shadows the enclosing scope")
+	assert.Equal(t, "retV_", block.declare("retV"), "shadows the package")
+	assert.Equal(t, "i2_", block.declare("i2"), "shadows an import")
+
+	sibling := fn.inner()
+	assert.Equal(t, "o__", sibling.declare("o"), "sibling blocks don't collide")
+}
+
+func TestExprIdent(t *testing.T) {
+	for src, want := range map[string]string{
+		"cp":            "cp",
+		"cp.Map":        "cp_Map",
+		"cp.S[i2].Map":  "cp_S_i2_Map",
+		"(*dst).Fields": "dst_Fields",
+	} {
+		e, err := parser.ParseExpr(src)
+		require.NoError(t, err)
+		assert.Equal(t, want, exprIdent(e), src)
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	src := []byte("func F() {\n\tif a == {\n\t}\n}\n")
+	_, err := parser.ParseFile(token.NewFileSet(), "", "package p\n\n"+string(src), parser.SkipObjectResolution)
+	require.Error(t, err)
+
+	assert.EqualError(t, sourceError(err, src, 2), `line 2:10: expected operand, found '{', in "if a == {"`)
+}
+
+func TestPrintDeclDoc(t *testing.T) {
+	fn := &ast.FuncDecl{
+		Doc:  &ast.CommentGroup{List: []*ast.Comment{{Text: "// F does it"}}},
+		Name: ident("F"),
+		Type: &ast.FuncType{Params: &ast.FieldList{}},
+		Body: &ast.BlockStmt{List: []ast.Stmt{define("x", ident("y"))}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printDecl(&buf, fn))
+	assert.Equal(t, "// F does it\nfunc F() {\n\tx := y\n}", buf.String())
+	assert.NotNil(t, fn.Doc, "the declaration is left untouched")
+}
@@ -0,0 +1,371 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// equalMethodName is the name of the generated equality methods, and of the
+// existing ones they call.
+const equalMethodName = "Equal"
+
+// generateEqualFunc builds the Equal method of the type, comparing the
+// members its deep copy method copies, and ignoring the skipped ones.
+func (g Generator) generateEqualFunc(p *packages.Package, obj object, skips skips, generating []object) *ast.FuncDecl {
+	kind := obj.Obj().Name()
+	var recv ast.Expr = ident(kind)
+	var ptr string
+	if g.isPtrRecv {
+		ptr = "*"
+		recv = &ast.StarExpr{X: recv}
+	}
+
+	sc := g.funcScope()
+	source, other := ident(sc.declare("o")), ident(sc.declare("other"))
+
+	var body []ast.Stmt
+	var a, b ast.Expr = source, other
+	if g.isPtrRecv {
+		body = append(body, &ast.IfStmt{
+			Cond: &ast.BinaryExpr{
+				X:  &ast.BinaryExpr{X: source, Op: token.EQL, Y: ident("nil")},
+				Op: token.LOR,
+				Y:  &ast.BinaryExpr{X: other, Op: token.EQL, Y: ident("nil")},
+			},
+			Body: &ast.BlockStmt{List: []ast.Stmt{
+				&ast.ReturnStmt{Results: []ast.Expr{&ast.BinaryExpr{X: source, Op: token.EQL, Y: other}}},
+			}},
+		})
+
+		a, b = derefRecv(source, other, obj)
+	}
+
+	q := equaler{g: g}
+	body = append(body, q.equal(a, b, g.equalPlanner(p.Name, skips, generating).plan("", "", obj, 0), sc, 0)...)
+	body = append(body, &ast.ReturnStmt{Results: []ast.Expr{ident("true")}})
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %s reports whether %s%s and %s are deeply equal", equalMethodName, ptr, kind, other.Name)},
+		}},
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{source}, Type: recv}}},
+		Name: ident(equalMethodName),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{other}, Type: recv}}},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ident("bool")}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
+
+// equalPlanner returns the planner of the equality methods, which reuse the
+// equality methods of the members.
+func (g Generator) equalPlanner(x string, skips skips, generating []object) planner {
+	return planner{g: g, x: x, skips: skips, find: func(v methoder) (string, bool, bool) {
+		hasMethod, isPointer := g.hasEqual(v, generating)
+		return equalMethodName, isPointer, hasMethod
+	}}
+}
+
+// derefRecv returns the values the pointer receiver and parameter of a
+// method of the type point to, as its plan compares them. The fields and
+// elements of structs and arrays are selected through the pointers.
+func derefRecv(source, other ast.Expr, obj object) (ast.Expr, ast.Expr) {
+	switch obj.Underlying().(type) {
+	case *types.Struct, *types.Array:
+		return source, other
+	default:
+		return &ast.StarExpr{X: source}, &ast.StarExpr{X: other}
+	}
+}
+
+// equaler builds the statements of equality methods from their plans.
+type equaler struct {
+	g Generator
+}
+
+// equal returns the statements comparing the values a and b following the
+// plan, returning false from the method as soon as they differ. The locals
+// are declared in sc. depth follows the one of the plan.
+func (q equaler) equal(a, b ast.Expr, plan Plan, sc *scope, depth int) []ast.Stmt {
+	g := q.g
+
+	switch n := plan.(type) {
+	case *Skip:
+		// Past the max depth, members are compared by reflection.
+		if n.Reason == PastMaxDepth {
+			return []ast.Stmt{differs(notDeepEqual(g, a, b))}
+		}
+		return nil
+	case *ShallowCopy:
+		if _, ok := n.Type.Underlying().(*types.Signature); ok {
+			// Functions are not comparable, so only their nilness is.
+			return []ast.Stmt{differs(nilDiffers(a, b))}
+		}
+		return []ast.Stmt{differs(notEqual(g, a, b, n.Type))}
+	case *ChanCopy:
+		// The deep copy method makes new channels, so only their nilness
+		// is compared.
+		return []ast.Stmt{differs(nilDiffers(a, b))}
+	case *MethodReuse:
+		reuse := differs(&ast.UnaryExpr{Op: token.NOT, X: call(selector(a, n.Method), methodArg(b, n))})
+		if !n.Pointer {
+			return []ast.Stmt{reuse}
+		}
+		return []ast.Stmt{differs(nilDiffers(a, b)), ifNotNil(a, reuse)}
+	}
+
+	depth++
+	switch n := plan.(type) {
+	case *StructCopy:
+		if opaque(n) {
+			return []ast.Stmt{differs(notEqual(g, a, b, n.Type))}
+		}
+
+		var stmts []ast.Stmt
+		for _, f := range n.Fields {
+			stmts = append(stmts, q.equal(selector(a, f.Name), selector(b, f.Name), f.Copy, sc, depth)...)
+		}
+		return stmts
+	case *ArrayCopy:
+		return q.elems(a, b, n.Elem, sc, depth)
+	case *SliceCopy:
+		return append(q.lengths(a, b), q.elems(a, b, n.Elem, sc, depth)...)
+	case *MapCopy:
+		stmts := q.lengths(a, b)
+		if skip, ok := n.Elem.(*Skip); ok && skip.Reason == SkippedBySelector {
+			return stmts
+		}
+
+		loopScope := sc.inner()
+		key, va, vb := loopScope.declare(indexed("k", depth)), loopScope.declare(indexed("va", depth)), loopScope.declare(indexed("vb", depth))
+
+		bodyScope := loopScope.inner()
+		ok := bodyScope.declare("ok")
+		eb := q.equal(ident(va), ident(vb), n.Elem, bodyScope, depth)
+
+		missing := &ast.UnaryExpr{Op: token.NOT, X: ident(ok)}
+		if len(eb) == 0 {
+			return append(stmts, &ast.RangeStmt{
+				Key: ident(key),
+				Tok: token.DEFINE,
+				X:   a,
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.IfStmt{
+					Init: lookup(ident("_"), ident(ok), b, key),
+					Cond: missing,
+					Body: returnFalse(),
+				}}},
+			})
+		}
+
+		body := []ast.Stmt{lookup(ident(vb), ident(ok), b, key), differs(missing)}
+		return append(stmts, &ast.RangeStmt{
+			Key:   ident(key),
+			Value: ident(va),
+			Tok:   token.DEFINE,
+			X:     a,
+			Body:  &ast.BlockStmt{List: append(body, eb...)},
+		})
+	case *PointerCopy:
+		stmts := []ast.Stmt{differs(nilDiffers(a, b))}
+
+		ea, eb := derefCompared(a, b, n.Elem)
+		if body := q.equal(ea, eb, n.Elem, sc.inner(), depth); len(body) > 0 {
+			stmts = append(stmts, ifNotNil(a, body...))
+		}
+		return stmts
+	default:
+		return nil
+	}
+}
+
+// lengths returns the statements comparing the lengths of the slices or
+// maps a and b, and their nilness unless nil and empty ones are equal.
+func (q equaler) lengths(a, b ast.Expr) []ast.Stmt {
+	var stmts []ast.Stmt
+	if !q.g.nilEqualsEmpty {
+		stmts = append(stmts, differs(nilDiffers(a, b)))
+	}
+
+	return append(stmts, differs(&ast.BinaryExpr{X: call(ident("len"), a), Op: token.NEQ, Y: call(ident("len"), b)}))
+}
+
+// elems returns the statements comparing the elements of the arrays or
+// slices a and b, of the same length, following the plan of the elements.
+func (q equaler) elems(a, b ast.Expr, elem Plan, sc *scope, depth int) []ast.Stmt {
+	loopScope := sc.inner()
+	idx := loopScope.declare(indexed("i", depth))
+
+	body := q.equal(index(a, idx), index(b, idx), elem, loopScope.inner(), depth)
+	if len(body) == 0 {
+		return nil
+	}
+
+	return []ast.Stmt{&ast.RangeStmt{
+		Key:  ident(idx),
+		Tok:  token.DEFINE,
+		X:    a,
+		Body: &ast.BlockStmt{List: body},
+	}}
+}
+
+// methodArg returns the argument of the method of the plan called on a
+// value compared with b, taking the address of b, or the value it points
+// to, as the method takes.
+func methodArg(b ast.Expr, n *MethodReuse) ast.Expr {
+	switch {
+	case n.Pointer == n.Result:
+		return b
+	case n.Pointer:
+		return &ast.StarExpr{X: b}
+	default:
+		return &ast.UnaryExpr{Op: token.AND, X: b}
+	}
+}
+
+// differs returns the statement returning false if cond holds.
+func differs(cond ast.Expr) ast.Stmt {
+	return &ast.IfStmt{Cond: cond, Body: returnFalse()}
+}
+
+func returnFalse() *ast.BlockStmt {
+	return &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{ident("false")}}}}
+}
+
+// nilDiffers returns the condition of one of a and b being nil, but not the
+// other.
+func nilDiffers(a, b ast.Expr) ast.Expr {
+	return &ast.BinaryExpr{
+		X:  &ast.ParenExpr{X: &ast.BinaryExpr{X: a, Op: token.EQL, Y: ident("nil")}},
+		Op: token.NEQ,
+		Y:  &ast.ParenExpr{X: &ast.BinaryExpr{X: b, Op: token.EQL, Y: ident("nil")}},
+	}
+}
+
+// notDeepEqual returns the condition of a and b not being deeply equal, by
+// reflection.
+func notDeepEqual(g Generator, a, b ast.Expr) ast.Expr {
+	return &ast.UnaryExpr{Op: token.NOT, X: call(selector(ident(g.importPath("reflect")), "DeepEqual"), a, b)}
+}
+
+// opaque reports whether the struct has unexported fields out of reach, as
+// the ones of a type of another package, so that it is compared as a whole,
+// skipped fields included.
+func opaque(n *StructCopy) bool {
+	for _, f := range n.Fields {
+		if skip, ok := f.Copy.(*Skip); ok && skip.Reason == UnexportedField {
+			return true
+		}
+	}
+
+	return false
+}
+
+// derefCompared returns the values the pointers a and b point to, as the
+// plan of the values compares them. The fields and elements of structs and
+// arrays are selected through the pointers, unless the structs are compared
+// as a whole.
+func derefCompared(a, b ast.Expr, elem Plan) (ast.Expr, ast.Expr) {
+	if s, ok := elem.(*StructCopy); ok && opaque(s) {
+		return &ast.StarExpr{X: a}, &ast.StarExpr{X: b}
+	}
+
+	return deref(a, b, elem)
+}
+
+// notEqual returns the condition of the values a and b of type t differing,
+// by the != operator if they are comparable without panicking, or else by
+// reflection.
+func notEqual(g Generator, a, b ast.Expr, t types.Type) ast.Expr {
+	if !strictlyComparable(t) {
+		return notDeepEqual(g, a, b)
+	}
+
+	return &ast.BinaryExpr{X: a, Op: token.NEQ, Y: b}
+}
+
+// strictlyComparable reports whether the values of the type are comparable,
+// and hold no interface values, whose comparison may panic.
+func strictlyComparable(t types.Type) bool {
+	if !types.Comparable(t) {
+		return false
+	}
+
+	switch t := t.Underlying().(type) {
+	case *types.Interface:
+		return false
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !strictlyComparable(t.Field(i).Type()) {
+				return false
+			}
+		}
+	case *types.Array:
+		return strictlyComparable(t.Elem())
+	}
+
+	return true
+}
+
+// lookup returns the definition of v and ok as the value of the map m at
+// key, and whether it is present.
+func lookup(v, ok ast.Expr, m ast.Expr, key string) ast.Stmt {
+	return &ast.AssignStmt{
+		Lhs: []ast.Expr{v, ok},
+		Tok: token.DEFINE,
+		Rhs: []ast.Expr{index(m, key)},
+	}
+}
+
+// hasEqual reports whether the type has an equality method, generated or
+// existing, and whether it takes a pointer.
+func (g Generator) hasEqual(v methoder, generating []object) (hasMethod, isPointer bool) {
+	if types.IsInterface(v) {
+		return false, false
+	}
+
+	for _, t := range generating {
+		if types.Identical(v, t) {
+			return true, g.isPtrRecv
+		}
+	}
+
+	for i := 0; i < v.NumMethods(); i++ {
+		if m := v.Method(i); m.Name() == equalMethodName {
+			return IsEqualMethod(m, equalMethodName)
+		}
+	}
+
+	return false, false
+}
+
+// IsEqualMethod reports whether m is an equality method with the given name:
+// it takes a single value of its receiver type, or a pointer to it, and
+// returns a bool. isPointer reports whether the parameter is a pointer.
+func IsEqualMethod(m *types.Func, name string) (ok, isPointer bool) {
+	if m.Name() != name {
+		return false, false
+	}
+
+	sig, _ := m.Type().(*types.Signature)
+	if sig == nil || sig.Recv() == nil || sig.Params().Len() != 1 || sig.Results().Len() != 1 {
+		return false, false
+	}
+
+	if res, ok := sig.Results().At(0).Type().Underlying().(*types.Basic); !ok || res.Kind() != types.Bool {
+		return false, false
+	}
+
+	paramType, paramPointer := reducePointer(sig.Params().At(0).Type())
+	recvType, _ := reducePointer(sig.Recv().Type())
+
+	if !types.Identical(paramType, recvType) {
+		return false, false
+	}
+
+	return true, paramPointer
+}
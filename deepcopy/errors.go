@@ -0,0 +1,87 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageErrors returns the errors of loading the package and its
+// dependencies, such as syntax and type errors.
+func PackageErrors(p *packages.Package) []packages.Error {
+	var errs []packages.Error
+	packages.Visit([]*packages.Package{p}, nil, func(q *packages.Package) {
+		errs = append(errs, q.Errors...)
+	})
+
+	return errs
+}
+
+// withPackageErrors adds the errors of the package, which are likely the
+// cause of err, to it.
+func withPackageErrors(err error, p *packages.Package) error {
+	errs := PackageErrors(p)
+	if len(errs) == 0 {
+		return err
+	}
+
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = "\t" + e.Error()
+	}
+
+	return fmt.Errorf("%w\npackage %s has errors:\n%s", err, p.PkgPath, strings.Join(lines, "\n"))
+}
+
+// checkValid returns an error if a member of the type, which is copied, has
+// an invalid type, most likely because of an error in the package.
+func checkValid(obj object) error {
+	sel, ok := findInvalid(obj, "", map[*types.Named]bool{})
+	switch {
+	case !ok:
+		return nil
+	case sel == "":
+		return fmt.Errorf("type %s is invalid", obj.Obj().Name())
+	default:
+		return fmt.Errorf("type %s: field %s has an invalid type", obj.Obj().Name(), sel)
+	}
+}
+
+// findInvalid returns the skip selector of the first member of t whose type
+// is invalid.
+func findInvalid(t types.Type, sel string, seen map[*types.Named]bool) (string, bool) {
+	t = types.Unalias(t)
+	if named, ok := t.(*types.Named); ok {
+		if seen[named] {
+			return "", false
+		}
+		seen[named] = true
+	}
+
+	switch v := t.Underlying().(type) {
+	case *types.Basic:
+		return sel, v.Kind() == types.Invalid
+	case *types.Struct:
+		for i := 0; i < v.NumFields(); i++ {
+			field := v.Field(i)
+			if s, ok := findInvalid(field.Type(), strings.TrimPrefix(sel+"."+field.Name(), "."), seen); ok {
+				return s, true
+			}
+		}
+	case *types.Pointer:
+		return findInvalid(v.Elem(), sel, seen)
+	case *types.Slice:
+		return findInvalid(v.Elem(), sel+"[i]", seen)
+	case *types.Array:
+		return findInvalid(v.Elem(), sel+"[i]", seen)
+	case *types.Map:
+		if s, ok := findInvalid(v.Key(), sel+"[k]", seen); ok {
+			return s, true
+		}
+		return findInvalid(v.Elem(), sel+"[k]", seen)
+	}
+
+	return "", false
+}
@@ -0,0 +1,187 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// CopyKind is how the deep copy method copies a member.
+type CopyKind string
+
+const (
+	// Deep members are copied into newly allocated memory, or have members
+	// that are.
+	Deep CopyKind = "deep"
+	// Shallow members are copied by the assignment of the enclosing value,
+	// which copies them fully unless they are interfaces or functions.
+	Shallow CopyKind = "shallow"
+	// Reused members are copied by an existing or generated deep copy method.
+	Reused CopyKind = "reuse"
+	// Skipped members are shared with the original, as a skip selector
+	// matches them.
+	Skipped CopyKind = "skip"
+	// CutOff members are shared with the original, as they are past the max
+	// depth.
+	CutOff CopyKind = "maxdepth"
+	// Aliased members are shared with the original, as they are unexported
+	// fields of a type of another package.
+	Aliased CopyKind = "alias"
+)
+
+// Explanation is the copy plan of a member of a type, as printed by
+// deep-copy explain.
+type Explanation struct {
+	// Selector is the path of the member, in the syntax of the skip
+	// selectors. It is empty for the type itself.
+	Selector string `json:"selector"`
+	// Key reports whether the member is the key of a map, rather than its
+	// value, both selected by [k].
+	Key  bool     `json:"key,omitempty"`
+	Type string   `json:"type"`
+	Copy CopyKind `json:"copy"`
+	// Reason details the copy: the skip selector, the reused method and how
+	// its result is converted, or why the member is shared.
+	Reason  string        `json:"reason,omitempty"`
+	Members []Explanation `json:"members,omitempty"`
+}
+
+// Explain returns the copy plans of the deep copy methods of the types of
+// the package, as they would be generated, without generating them.
+func (g Generator) Explain(types []string, p *packages.Package) ([]Explanation, error) {
+	plans, err := g.CopyPlans(types, p)
+	if err != nil {
+		return nil, err
+	}
+
+	e := explainer{pkg: p.Types, maxDepth: g.maxDepth}
+	explanations := make([]Explanation, len(plans))
+	for i, plan := range plans {
+		explanations[i] = e.explain(plan)
+	}
+
+	return explanations, nil
+}
+
+// explainer describes the nodes of copy plans.
+type explainer struct {
+	pkg      *types.Package
+	maxDepth int
+}
+
+// explain returns the explanation of the node of a copy plan.
+func (e explainer) explain(plan Plan) Explanation {
+	m := plan.At()
+	t := types.Unalias(m.Type)
+	ex := Explanation{Selector: m.Selector, Type: e.typeString(t), Copy: Shallow}
+
+	switch n := plan.(type) {
+	case *Skip:
+		switch n.Reason {
+		case SkippedBySelector:
+			ex.Copy, ex.Reason = Skipped, "skipped by "+n.By
+		case PastMaxDepth:
+			ex.Copy, ex.Reason = CutOff, fmt.Sprintf("past max depth %d, shared", e.maxDepth)
+		case UnexportedField:
+			if holdsReferences(t, map[types.Type]bool{}) {
+				ex.Copy, ex.Reason = Aliased, "unexported field of a type of another package"
+			}
+		}
+	case *MethodReuse:
+		ex.Copy = Reused
+		switch {
+		case n.Pointer == n.Result:
+			ex.Reason = fmt.Sprintf("calls %s, assigning its result", n.Method)
+		case n.Pointer:
+			ex.Reason = fmt.Sprintf("calls %s, taking the address of its result", n.Method)
+		default:
+			ex.Reason = fmt.Sprintf("calls %s, dereferencing its result", n.Method)
+		}
+	case *StructCopy:
+		for _, f := range n.Fields {
+			ex.Members = append(ex.Members, e.explain(f.Copy))
+		}
+		ex.Copy = deepest(ex.Members)
+	case *ArrayCopy:
+		ex.Members = []Explanation{e.explain(n.Elem)}
+		ex.Copy = deepest(ex.Members)
+	case *SliceCopy:
+		ex.Copy = Deep
+		ex.Members = []Explanation{e.explain(n.Elem)}
+	case *MapCopy:
+		ex.Copy = Deep
+		key := e.explain(n.Key)
+		key.Key = true
+		ex.Members = []Explanation{key, e.explain(n.Elem)}
+	case *PointerCopy:
+		ex.Copy = Deep
+
+		// The pointed to value is copied in place of the pointer.
+		elem := e.explain(n.Elem)
+		ex.Members = elem.Members
+		if elem.Copy == CutOff {
+			ex.Reason = "the pointed to value is " + elem.Reason
+		}
+	case *ChanCopy:
+		ex.Copy, ex.Reason = Deep, "new channel of the same capacity, the buffered values are not copied"
+	case *ShallowCopy:
+		switch t.Underlying().(type) {
+		case *types.Interface:
+			ex.Reason = "the dynamic value is shared"
+		case *types.Signature:
+			ex.Reason = "functions are shared"
+		}
+	}
+
+	return ex
+}
+
+// typeString prints the type, qualified by the name of its package when it
+// is declared in another one.
+func (e explainer) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == e.pkg {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+// deepest returns Deep if any of the members is copied into newly allocated
+// memory, or by a method, and Shallow otherwise.
+func deepest(members []Explanation) CopyKind {
+	for _, m := range members {
+		if m.Copy == Deep || m.Copy == Reused {
+			return Deep
+		}
+	}
+
+	return Shallow
+}
+
+// holdsReferences reports whether the values of type t reference memory
+// that copies by assignment share.
+func holdsReferences(t types.Type, seen map[types.Type]bool) bool {
+	t = types.Unalias(t)
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Kind() == types.UnsafePointer
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if holdsReferences(u.Field(i).Type(), seen) {
+				return true
+			}
+		}
+		return false
+	case *types.Array:
+		return holdsReferences(u.Elem(), seen)
+	default:
+		return true
+	}
+}
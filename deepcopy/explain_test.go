@@ -0,0 +1,65 @@
+package deepcopy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata/explain")
+	require.NoError(t, err)
+
+	g := NewGenerator(
+		WithSkipLists(SkipLists{{"Notes": struct{}{}}}),
+		WithMaxDepth(3),
+	)
+	explanations, err := g.Explain([]string{"Plan"}, pkgs[0])
+	require.NoError(t, err)
+	require.Len(t, explanations, 1)
+
+	assert.Equal(t, []string{
+		"Plan: deep",
+		"  Name string: shallow",
+		"  Next *Plan: reuse, calls DeepCopy, taking the address of its result",
+		"  Steps []Step: deep",
+		"    Steps[i] Step: reuse, calls DeepCopy, assigning its result",
+		"  Index map[string]*Step: deep",
+		"    Index[k] string (key): shallow",
+		"    Index[k] *Step: reuse, calls DeepCopy, taking the address of its result",
+		"  At time.Time: shallow",
+		"    At.wall uint64: shallow",
+		"    At.ext int64: shallow",
+		"    At.loc *time.Location: alias, unexported field of a type of another package",
+		"  Hook func(): shallow, functions are shared",
+		"  Notes []string: skip, skipped by Notes",
+		"  Level Level1: deep",
+		"    Level.Next *Level2: deep, the pointed to value is past max depth 3, shared",
+		"  Slots [2]*Step: deep",
+		"    Slots[i] *Step: reuse, calls DeepCopy, taking the address of its result",
+	}, explanationLines(explanations[0], 0))
+}
+
+func explanationLines(e Explanation, indent int) []string {
+	line := fmt.Sprintf("%s%s %s", strings.Repeat("  ", indent), e.Selector, e.Type)
+	if e.Selector == "" {
+		line = e.Type
+	}
+	if e.Key {
+		line += " (key)"
+	}
+	line += ": " + string(e.Copy)
+	if e.Reason != "" {
+		line += ", " + e.Reason
+	}
+
+	lines := []string{line}
+	for _, m := range e.Members {
+		lines = append(lines, explanationLines(m, indent+1)...)
+	}
+
+	return lines
+}
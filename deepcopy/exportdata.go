@@ -0,0 +1,122 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"runtime"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadExportData loads the packages matching the patterns, along with the
+// export data of their dependencies, which the build system compiles or takes
+// from its cache. Only the packages themselves are type-checked from source.
+func loadExportData(cfg LoadConfig, overlay map[string][]byte, patterns ...string) ([]*packages.Package, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedDeps | packages.NeedExportFile | packages.NeedModule,
+		Tests:      cfg.Tests,
+		BuildFlags: cfg.buildFlags(),
+		Env:        cfg.env(),
+		Overlay:    overlay,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	arch := cfg.GOARCH
+	if arch == "" {
+		arch = runtime.GOARCH
+	}
+
+	fset := token.NewFileSet()
+	for _, p := range pkgs {
+		checkPackage(fset, p, overlay, types.SizesFor("gc", arch))
+	}
+
+	return pkgs, nil
+}
+
+// checkPackage parses and type-checks the package, importing its dependencies
+// from their export data. Errors are recorded in the package, as
+// packages.Load does.
+func checkPackage(fset *token.FileSet, p *packages.Package, overlay map[string][]byte, sizes types.Sizes) {
+	p.Fset = fset
+	p.TypesSizes = sizes
+
+	for _, name := range p.CompiledGoFiles {
+		var src any
+		if b, ok := overlay[name]; ok {
+			src = b
+		}
+
+		f, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+		if list, ok := err.(scanner.ErrorList); ok {
+			for _, e := range list {
+				p.Errors = append(p.Errors, packages.Error{Pos: e.Pos.String(), Msg: e.Msg, Kind: packages.ParseError})
+			}
+		} else if err != nil {
+			p.Errors = append(p.Errors, packages.Error{Pos: name, Msg: err.Error(), Kind: packages.ParseError})
+		}
+		if f != nil {
+			p.Syntax = append(p.Syntax, f)
+		}
+	}
+
+	p.TypesInfo = &types.Info{
+		Types:        map[ast.Expr]types.TypeAndValue{},
+		Defs:         map[*ast.Ident]types.Object{},
+		Uses:         map[*ast.Ident]types.Object{},
+		Implicits:    map[ast.Node]types.Object{},
+		Instances:    map[*ast.Ident]types.Instance{},
+		Scopes:       map[ast.Node]*types.Scope{},
+		Selections:   map[*ast.SelectorExpr]*types.Selection{},
+		FileVersions: map[*ast.File]string{},
+	}
+
+	conf := types.Config{
+		Importer: exportDataImporter(fset, p),
+		Sizes:    sizes,
+		Error: func(err error) {
+			terr := err.(types.Error)
+			p.Errors = append(p.Errors, packages.Error{Pos: fset.Position(terr.Pos).String(), Msg: terr.Msg, Kind: packages.TypeError})
+		},
+	}
+	if p.Module != nil && p.Module.GoVersion != "" {
+		conf.GoVersion = "go" + p.Module.GoVersion
+	}
+
+	p.Types, _ = conf.Check(p.PkgPath, fset, p.Syntax, p.TypesInfo)
+	p.IllTyped = len(p.Errors) > 0
+}
+
+// exportDataImporter imports the dependencies of the package from the export
+// data files the build system listed.
+func exportDataImporter(fset *token.FileSet, p *packages.Package) types.Importer {
+	files := map[string]string{}
+	// The direct imports are keyed by import path, and resolve to the test
+	// variants of the packages when p is one.
+	for path, imp := range p.Imports {
+		files[path] = imp.ExportFile
+	}
+	packages.Visit([]*packages.Package{p}, nil, func(q *packages.Package) {
+		if _, ok := files[q.PkgPath]; !ok && q != p {
+			files[q.PkgPath] = q.ExportFile
+		}
+	})
+
+	return importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		file := files[path]
+		if file == "" {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+
+		return os.Open(file)
+	})
+}
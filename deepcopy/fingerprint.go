@@ -0,0 +1,78 @@
+package deepcopy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Fingerprint returns a hash of the contents of the files of the packages
+// matching the patterns and of all their dependencies, along with the
+// configuration they are loaded with. It only lists the files, which is much
+// cheaper than loading types, so that callers can skip generating for
+// packages whose fingerprint did not change. The excluded files are left out.
+func Fingerprint(cfg LoadConfig, patterns ...string) (string, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+		Tests:      cfg.Tests,
+		BuildFlags: cfg.buildFlags(),
+		Env:        cfg.env(),
+		Overlay:    cfg.Overlay,
+	}, patterns...)
+	if err != nil {
+		return "", err
+	}
+
+	excluded := map[string]bool{}
+	for _, path := range cfg.Exclude {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		excluded[abs] = true
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "tests=%t tags=%q goos=%q goarch=%q\n", cfg.Tests, cfg.BuildTags, cfg.GOOS, cfg.GOARCH)
+	fmt.Fprintf(h, "patterns=%q\n", patterns)
+
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		if err != nil {
+			return
+		}
+
+		fmt.Fprintf(h, "package %s\n", p.ID)
+		if p.Module != nil {
+			fmt.Fprintf(h, "go %s\n", p.Module.GoVersion)
+		}
+		for _, e := range p.Errors {
+			fmt.Fprintf(h, "error %s\n", e)
+		}
+
+		for _, name := range slices.Concat(p.GoFiles, p.OtherFiles) {
+			if excluded[name] {
+				continue
+			}
+
+			src, ok := cfg.Overlay[name]
+			if !ok {
+				src, err = os.ReadFile(name)
+				if err != nil {
+					return
+				}
+			}
+			fmt.Fprintf(h, "file %s %d\n", name, len(src))
+			h.Write(src)
+		}
+	})
+	if err != nil {
+		return "", fmt.Errorf("hashing package files: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -2,16 +2,17 @@ package deepcopy
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/token"
 	"go/types"
+	"go/version"
 	"io"
 	"log"
-	"os"
 	"regexp"
-	"strconv"
 	"strings"
+	"text/template"
 
 	"golang.org/x/tools/go/packages"
 )
@@ -32,9 +33,51 @@ type Generator struct {
 	methodName string
 	skipLists  SkipLists
 	buildTags  []string
-
+	markers    bool
+	goVersion  string
+
+	// header is the template of the banner written ahead of the generated
+	// code marker, as in a license.
+	header *template.Template
+
+	// all, typePattern and excludedTypes select the struct types of the
+	// package to generate, in addition to the requested ones.
+	all           bool
+	typePattern   *regexp.Regexp
+	excludedTypes []string
+
+	// equal generates an Equal method along with every deep copy method,
+	// considering nil and empty slices and maps equal if nilEqualsEmpty.
+	equal          bool
+	nilEqualsEmpty bool
+
+	// diff, size and merge generate Diff, DeepSize and MergeFrom methods
+	// along with every deep copy method.
+	diff  bool
+	size  bool
+	merge bool
+
+	// conversions are the struct to struct converter functions to generate.
+	conversions []Conversion
+
+	// clone reports whether the generated code can use slices.Clone and
+	// maps.Clone, which the Go version of the package allows.
+	clone bool
+
+	// scope is the scope of the package being generated, which names
+	// declared by the generated code must not shadow.
+	scope *types.Scope
+
+	// imports and decls collect the imports and declarations of the file
+	// being generated. Generate gives each call its own, so that a Generator
+	// can be used concurrently.
 	imports map[string]string
-	fns     [][]byte
+	decls   []ast.Decl
+
+	// warnings collect the warnings of the file being generated, which are
+	// returned rather than logged, so that they are reported in order when
+	// packages are generated concurrently.
+	warnings *[]string
 }
 
 // GeneratorOption is a function to specify option for NewGenerator.
@@ -75,12 +118,99 @@ func WithBuildTags(bts []string) GeneratorOption {
 	}
 }
 
+// WithMarkers is an option to select types and interface methods from
+// +k8s:deepcopy-gen markers, generating DeepCopyInto, DeepCopy and interface
+// methods compatible with zz_generated.deepcopy.go files.
+func WithMarkers(f bool) GeneratorOption {
+	return func(g *Generator) {
+		g.markers = f
+	}
+}
+
+// WithGoVersion is an option to specify the minimum Go version of the
+// generated code, instead of the go directive of the package's module.
+func WithGoVersion(v string) GeneratorOption {
+	return func(g *Generator) {
+		g.goVersion = v
+	}
+}
+
+// WithAllTypes is an option to generate every exported named struct type of
+// the package.
+func WithAllTypes(f bool) GeneratorOption {
+	return func(g *Generator) {
+		g.all = f
+	}
+}
+
+// WithTypePattern is an option to generate the exported named struct types of
+// the package whose name matches re.
+func WithTypePattern(re *regexp.Regexp) GeneratorOption {
+	return func(g *Generator) {
+		g.typePattern = re
+	}
+}
+
+// WithExcludedTypes is an option to leave out types selected by WithAllTypes,
+// WithTypePattern or WithMarkers. Explicitly requested types are kept.
+func WithExcludedTypes(names []string) GeneratorOption {
+	return func(g *Generator) {
+		g.excludedTypes = names
+	}
+}
+
+// WithEqual is an option to also generate an Equal method on every type,
+// comparing the members the deep copy method copies.
+func WithEqual(f bool) GeneratorOption {
+	return func(g *Generator) {
+		g.equal = f
+	}
+}
+
+// WithNilEqualsEmpty is an option to consider nil and empty slices and maps
+// equal in the generated Equal and Diff methods.
+func WithNilEqualsEmpty(f bool) GeneratorOption {
+	return func(g *Generator) {
+		g.nilEqualsEmpty = f
+	}
+}
+
+// WithDiff is an option to also generate a Diff method on every type,
+// reporting the changes of the members the deep copy method copies.
+func WithDiff(f bool) GeneratorOption {
+	return func(g *Generator) {
+		g.diff = f
+	}
+}
+
+// WithSize is an option to also generate a DeepSize method on every type,
+// estimating the memory the value and its deep copy hold.
+func WithSize(f bool) GeneratorOption {
+	return func(g *Generator) {
+		g.size = f
+	}
+}
+
+// WithMerge is an option to also generate a MergeFrom method on every type,
+// overlaying the non-zero members of a value onto the receiver.
+func WithMerge(f bool) GeneratorOption {
+	return func(g *Generator) {
+		g.merge = f
+	}
+}
+
+// WithConversions generates a converter function for every conversion, along
+// with the deep copy methods of the types.
+func WithConversions(c []Conversion) GeneratorOption {
+	return func(g *Generator) {
+		g.conversions = c
+	}
+}
+
 // NewGenerator generates a Generator with options.
 func NewGenerator(opts ...GeneratorOption) Generator {
 	g := Generator{
 		methodName: "DeepCopy",
-		imports:    map[string]string{},
-		fns:        [][]byte{},
 	}
 	for _, opt := range opts {
 		opt(&g)
@@ -113,263 +243,427 @@ func (s skips) Contains(sel string) bool {
 	return false
 }
 
+// Generate writes the deep copy methods of the types of the package to w,
+// logging the warnings about the members the methods leave out.
 func (g Generator) Generate(w io.Writer, types []string, p *packages.Package) error {
+	warnings, err := g.GenerateWithWarnings(w, types, p)
+	for _, warning := range warnings {
+		log.Printf("WARNING: %s", warning)
+	}
+
+	return err
+}
+
+// GenerateWithWarnings writes the deep copy methods of the types of the
+// package to w, as Generate does. It returns the warnings about the members
+// the methods leave out, in the order they were generated in, rather than
+// logging them, so that the ones of packages generated concurrently can be
+// reported in order.
+func (g Generator) GenerateWithWarnings(w io.Writer, types []string, p *packages.Package) ([]string, error) {
+	if g.markers {
+		g.isPtrRecv = true
+	}
+
+	// The markers of the types are warned about as they are located.
+	g.warnings = new([]string)
+
+	objs, markers, err := g.locateTypes(types, p)
+	if err != nil {
+		return nil, withPackageErrors(err, p)
+	}
+
+	for _, obj := range objs {
+		if err := checkValid(obj); err != nil {
+			return nil, withPackageErrors(err, p)
+		}
+	}
+
+	convs, err := g.locateConversions(p)
+	if err != nil {
+		return nil, withPackageErrors(err, p)
+	}
+
+	g.scope = p.Types.Scope()
+	g.imports = map[string]string{}
+	g.decls = nil
+
+	g.clone, err = g.canClone(p)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, obj := range objs {
+		kind := obj.Obj().Name()
+
+		if g.markers {
+			decls, err := g.generateMarkerFuncs(p, obj, markers[kind], g.skipLists.Get(i), objs)
+			if err != nil {
+				return nil, fmt.Errorf("generating method: %v", err)
+			}
+			g.decls = append(g.decls, decls...)
+		} else {
+			g.decls = append(g.decls, g.generateFunc(p, obj, g.skipLists.Get(i), objs))
+		}
+
+		if g.equal {
+			g.decls = append(g.decls, g.generateEqualFunc(p, obj, g.skipLists.Get(i), objs))
+		}
+
+		if g.diff {
+			g.decls = append(g.decls, g.generateDiffFunc(p, obj, g.skipLists.Get(i), objs))
+		}
+
+		if g.size {
+			g.decls = append(g.decls, g.generateSizeFunc(p, obj, g.skipLists.Get(i), objs))
+		}
+
+		if g.merge {
+			fn, err := g.generateMergeFunc(p, obj, g.skipLists.Get(i), objs)
+			if err != nil {
+				return nil, fmt.Errorf("generating merge method of %s: %v", kind, err)
+			}
+			g.decls = append(g.decls, fn)
+		}
+	}
+
+	for _, conv := range convs {
+		fn, err := g.generateConvertFunc(p, conv, convs, objs)
+		if err != nil {
+			return nil, fmt.Errorf("generating converter %s: %v", conv.fn, err)
+		}
+		g.decls = append(g.decls, fn)
+	}
+
+	err = g.generateFile(w, p, objs)
+	if err != nil {
+		return nil, withPackageErrors(fmt.Errorf("generating file content: %v", err), p)
+	}
+
+	return *g.warnings, nil
+}
+
+// GeneratedTypes returns the names of the types of the package whose deep
+// copy methods are generated, in the order they are generated in: the
+// requested ones, followed by the ones selected by markers, --all or the type
+// pattern.
+func (g Generator) GeneratedTypes(types []string, p *packages.Package) ([]string, error) {
+	objs, _, err := g.locateTypes(types, p)
+	if err != nil {
+		return nil, withPackageErrors(err, p)
+	}
+
+	names := make([]string, len(objs))
+	for i, obj := range objs {
+		names[i] = obj.Obj().Name()
+	}
+
+	return names, nil
+}
+
+// locateTypes resolves the requested types, along with the ones selected by
+// markers, all or typePattern, in the package.
+func (g Generator) locateTypes(types []string, p *packages.Package) ([]object, map[string]typeMarkers, error) {
+	var markers map[string]typeMarkers
+	if g.markers {
+		types, markers = g.withMarkedTypes(types, p)
+	}
+
+	if g.all || g.typePattern != nil {
+		types = g.withSelectedTypes(types, p)
+	}
+
 	objs := make([]object, len(types))
 	for i, kind := range types {
 		obj, err := locateType(kind, p)
 		if err != nil {
-			return fmt.Errorf("locating type %q in %q: %v", kind, p.Name, err)
+			return nil, nil, fmt.Errorf("locating type %q in %q: %v", kind, p.Name, err)
 		}
 
 		objs[i] = obj
 	}
 
-	for i, obj := range objs {
-		fn, err := g.generateFunc(p, obj, g.skipLists.Get(i), objs)
-		if err != nil {
-			return fmt.Errorf("generating method: %v", err)
-		}
+	return objs, markers, nil
+}
 
-		g.fns = append(g.fns, fn)
+// canClone reports whether slices.Clone and maps.Clone, added in Go 1.21, are
+// available to the package, given the go directive of its module or the
+// version the generator was configured with.
+func (g Generator) canClone(p *packages.Package) (bool, error) {
+	v := g.goVersion
+	if v == "" && p.Module != nil {
+		v = p.Module.GoVersion
+	}
+	if v == "" {
+		return false, nil
 	}
 
-	err := g.generateFile(w, p)
-	if err != nil {
-		return fmt.Errorf("generating file content: %v", err)
+	lang := v
+	if !strings.HasPrefix(lang, "go") {
+		lang = "go" + lang
+	}
+	if !version.IsValid(lang) {
+		return false, fmt.Errorf("invalid Go version %q", v)
 	}
 
-	return nil
+	return version.Compare(lang, "go1.21") >= 0, nil
 }
 
-func (g Generator) generateFunc(p *packages.Package, obj object, skips skips, generating []object) ([]byte, error) {
-	var buf bytes.Buffer
+// warn records a warning of the file being generated. Outside of Generate,
+// the warnings are dropped.
+func (g Generator) warn(format string, args ...any) {
+	if g.warnings != nil {
+		*g.warnings = append(*g.warnings, fmt.Sprintf(format, args...))
+	}
+}
 
+// generateFunc builds the deep copy method of the type.
+func (g Generator) generateFunc(p *packages.Package, obj object, skips skips, generating []object) *ast.FuncDecl {
+	kind := obj.Obj().Name()
+	var recv, result ast.Expr = ident(kind), ident(kind)
 	var ptr string
 	if g.isPtrRecv {
 		ptr = "*"
+		recv, result = &ast.StarExpr{X: recv}, &ast.StarExpr{X: result}
 	}
-	kind := obj.Obj().Name()
-
-	source := "o"
-	fmt.Fprintf(&buf, `// %s generates a deep copy of %s%s
-func (o %s%s) %s() %s%s {
-	var cp %s = %s%s
-`, g.methodName, ptr, kind, ptr, kind, g.methodName, ptr, kind, kind, ptr, source)
 
-	g.walkType(source, "cp", p.Name, obj, &buf, skips, generating, 0)
+	sc := g.funcScope()
+	source, sink := ident(sc.declare("o")), ident(sc.declare("cp"))
 
+	var body []ast.Stmt
+	var value ast.Expr = source
 	if g.isPtrRecv {
-		buf.WriteString("return &cp\n}")
-	} else {
-		buf.WriteString("return cp\n}")
+		value = &ast.StarExpr{X: source}
 	}
+	body = append(body, varDecl(sink.Name, ident(kind), value))
 
-	return buf.Bytes(), nil
+	e := emitter{g: g, x: p.Name, root: kind}
+	body = append(body, e.emit(source, sink, g.copyPlan(obj, p.Name, skips, generating), sc, 0)...)
+
+	var ret ast.Expr = sink
+	if g.isPtrRecv {
+		ret = &ast.UnaryExpr{Op: token.AND, X: sink}
+	}
+	body = append(body, &ast.ReturnStmt{Results: []ast.Expr{ret}})
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %s generates a deep copy of %s%s", g.methodName, ptr, kind)},
+		}},
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{source}, Type: recv}}},
+		Name: ident(g.methodName),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: result}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
 }
 
-func (g Generator) generateFile(w io.Writer, p *packages.Package) error {
+func (g Generator) generateFile(w io.Writer, p *packages.Package, objs []object) error {
 	var file bytes.Buffer
 
-	fmt.Fprintf(&file, "// Code generated by deep-copy %s; DO NOT EDIT.\n\npackage %s\n\n", strings.Join(os.Args[1:], " "), p.Name)
-
-	for _, tag := range g.buildTags {
-		fmt.Fprintf(&file, "//go:build %s\n// +build %s\n", tag, tag)
+	if err := g.writeHeader(&file, p, objs); err != nil {
+		return err
 	}
 
 	if len(g.imports) > 0 {
-		file.WriteString("import (\n")
-		for name, path := range g.imports {
-			if strings.HasSuffix(path, name) {
-				fmt.Fprintf(&file, "%q\n", path)
-			} else {
-				fmt.Fprintf(&file, "%s %q\n", name, path)
-			}
+		if err := printDecl(&file, g.importDecl()); err != nil {
+			return err
 		}
-		file.WriteString(")\n")
+		file.WriteString("\n\n")
 	}
 
-	for _, fn := range g.fns {
-		file.Write(fn)
+	for _, d := range g.decls {
+		if err := printDecl(&file, d); err != nil {
+			return err
+		}
 		file.WriteString("\n\n")
 	}
 
 	b, err := format.Source(file.Bytes())
 	if err != nil {
-		return fmt.Errorf("error formatting source: %w\nsource:\n%s", err, file.String())
+		return fmt.Errorf("formatting source: %w", sourceError(err, file.Bytes(), 0))
+	}
+
+	// The copies and receivers of the deep copy methods are the roots of
+	// the selectors errors are reported at.
+	if err := typeCheck(p, b, []string{g.local("cp"), g.local("o")}); err != nil {
+		return fmt.Errorf("type-checking generated source: %w", err)
 	}
 
 	_, err = w.Write(b)
 	return err
 }
 
-func (g Generator) walkType(source, sink, x string, m types.Type, w io.Writer, skips skips, generating []object, depth int) {
-	initial := depth == 0
-	if m == nil {
-		return
-	}
+// emitter builds the statements of copy plans, for the type named root of
+// the package named x.
+type emitter struct {
+	g    Generator
+	x    string
+	root string
+}
 
-	if g.maxDepth > 0 {
-		if depth >= g.maxDepth {
-			p := strings.Split(sink, ".")
-			stoppedAt := strings.TrimSuffix(fmt.Sprintf("%s.%s", generating[0], strings.Join(p[1:len(p)-1], ".")), ".")
-			log.Printf("WARNING: reached max depth %d. stop recursion at %s", depth, stoppedAt)
-			return
+// emit returns the statements copying source into sink, which holds an
+// assignment of it, following the plan. The locals are declared in sc. depth
+// follows the one of the plan, naming the loop variables.
+func (e emitter) emit(source, sink ast.Expr, plan Plan, sc *scope, depth int) []ast.Stmt {
+	g, x := e.g, e.x
+
+	switch n := plan.(type) {
+	case *Skip:
+		if n.Reason == PastMaxDepth {
+			stoppedAt := strings.TrimSuffix(e.root+"."+n.Selector, ".")
+			g.warn("reached max depth %d. stop recursion at %s", g.maxDepth, stoppedAt)
 		}
-	}
-
-	var needExported bool
-	switch v := m.(type) {
-	case *types.Named:
-		if v.Obj().Pkg() != nil && v.Obj().Pkg().Name() != x {
-			needExported = true
+		return nil
+	case *ShallowCopy:
+		return nil
+	case *MethodReuse:
+		if n.Pointer {
+			return []ast.Stmt{ifNotNil(source, e.reuse(source, sink, n, sc.inner())...)}
 		}
-	}
-
-	if v, ok := m.(methoder); ok && !initial && g.reuseDeepCopy(source, sink, v, false, generating, w) {
-		return
+		return e.reuse(source, sink, n, sc)
 	}
 
 	depth++
-	under := m.Underlying()
-	switch v := under.(type) {
-	case *types.Struct:
-		for i := 0; i < v.NumFields(); i++ {
-			field := v.Field(i)
-			if needExported && !field.Exported() {
-				continue
-			}
-			fname := field.Name()
-			sel := sink + "." + fname
-			sel = sel[strings.Index(sel, ".")+1:]
-			if _, ok := skips[sel]; ok {
-				continue
-			}
-			g.walkType(source+"."+fname, sink+"."+fname, x, field.Type(), w, skips, generating, depth)
+	switch n := plan.(type) {
+	case *StructCopy:
+		var stmts []ast.Stmt
+		for _, f := range n.Fields {
+			stmts = append(stmts, e.emit(selector(source, f.Name), selector(sink, f.Name), f.Copy, sc, depth)...)
 		}
-	case *types.Slice:
-		kind := g.getElemType(v.Elem(), x)
-
-		idx := "i"
-		if depth > 1 {
-			idx += strconv.Itoa(depth)
+		return stmts
+	case *ArrayCopy:
+		loopScope := sc.inner()
+		idx := loopScope.declare(indexed("i", depth))
+
+		body := e.emit(index(source, idx), index(sink, idx), n.Elem, loopScope.inner(), depth)
+		if len(body) == 0 {
+			return nil
 		}
 
-		// sel is only used for skips
-		sel := "[i]"
-		sel = sel[strings.Index(sel, ".")+1:]
-		if !initial {
-			sel = sink + sel
+		return []ast.Stmt{&ast.RangeStmt{
+			Key:  ident(idx),
+			Tok:  token.DEFINE,
+			X:    source,
+			Body: &ast.BlockStmt{List: body},
+		}}
+	case *SliceCopy:
+		ifScope := sc.inner()
+		loopScope := ifScope.inner()
+		idx := loopScope.declare(indexed("i", depth))
+
+		body := e.emit(index(source, idx), index(sink, idx), n.Elem, loopScope.inner(), depth)
+
+		// slices.Clone keeps nil slices nil, like the nil check below.
+		if len(body) == 0 && g.clone {
+			return []ast.Stmt{assign(sink, call(selector(ident(g.importPath("slices")), "Clone"), source))}
 		}
 
-		var skipSlice bool
-		if skips.Contains(sel) {
-			skipSlice = true
-		}
-
-		fmt.Fprintf(w, `if %s != nil {
-	%s = make([]%s, len(%s))
-`, source, sink, kind, source)
-
-		fmt.Fprintf(w, `copy(%s, %s)
-`, sink, source)
-
-		var b bytes.Buffer
-
-		if !skipSlice {
-			baseSel := "[" + idx + "]"
-			g.walkType(source+baseSel, sink+baseSel, x, v.Elem(), &b, skips, generating, depth)
+		kind := g.typeExpr(n.Elem.At().Type, x)
+		stmts := []ast.Stmt{
+			assign(sink, call(ident("make"), &ast.ArrayType{Elt: kind}, call(ident("len"), source))),
+			&ast.ExprStmt{X: call(ident("copy"), sink, source)},
 		}
-
-		if b.Len() > 0 {
-			fmt.Fprintf(w, `    for %s := range %s {
-`, idx, source)
-
-			b.WriteTo(w)
-
-			fmt.Fprintf(w, "}\n")
+		if len(body) > 0 {
+			stmts = append(stmts, &ast.RangeStmt{
+				Key:  ident(idx),
+				Tok:  token.DEFINE,
+				X:    source,
+				Body: &ast.BlockStmt{List: body},
+			})
 		}
 
-		fmt.Fprintf(w, "}\n")
-	case *types.Pointer:
-		fmt.Fprintf(w, "if %s != nil {\n", source)
+		return []ast.Stmt{ifNotNil(source, stmts...)}
+	case *PointerCopy:
+		kind := g.typeExpr(n.Type.Underlying().(*types.Pointer).Elem(), x)
 
-		if e, ok := v.Elem().(methoder); !ok || initial || !g.reuseDeepCopy(source, sink, e, true, generating, w) {
-			kind := g.getElemType(v.Elem(), x)
-
-			fmt.Fprintf(w, `%s = new(%s)
-	*%s = *%s
-`, sink, kind, sink, source)
-
-			g.walkType(source, sink, x, v.Elem(), w, skips, generating, depth)
+		ifScope := sc.inner()
+		stmts := []ast.Stmt{
+			assign(sink, call(ident("new"), kind)),
+			assign(&ast.StarExpr{X: sink}, &ast.StarExpr{X: source}),
 		}
+		src, dst := deref(source, sink, n.Elem)
+		stmts = append(stmts, e.emit(src, dst, n.Elem, ifScope, depth)...)
 
-		fmt.Fprintf(w, "}\n")
-	case *types.Chan:
-		kind := g.getElemType(v.Elem(), x)
+		return []ast.Stmt{ifNotNil(source, stmts...)}
+	case *ChanCopy:
+		kind := g.typeExpr(n.Type.Underlying().(*types.Chan).Elem(), x)
 
-		fmt.Fprintf(w, `if %s != nil {
-	%s = make(chan %s, cap(%s))
-}
-`, source, sink, kind, source)
-	case *types.Map:
-		kkind := g.getElemType(v.Key(), x)
-		vkind := g.getElemType(v.Elem(), x)
+		return []ast.Stmt{ifNotNil(source,
+			assign(sink, call(ident("make"), &ast.ChanType{Dir: ast.SEND | ast.RECV, Value: kind}, call(ident("cap"), source))),
+		)}
+	case *MapCopy:
+		v := n.Type.Underlying().(*types.Map)
 
-		key, val := "k", "v"
-
-		if depth > 1 {
-			key += strconv.Itoa(depth)
-			val += strconv.Itoa(depth)
-		}
-
-		// Sel is only used for skips
-		sel := "[k]"
-		if !initial {
-			sel = sink + sel
-		}
-		sel = sel[strings.Index(sel, ".")+1:]
-
-		var skipKey, skipValue bool
-		if skips.Contains(sel) {
-			skipKey, skipValue = true, true
-		}
-
-		fmt.Fprintf(w, `if %s != nil {
-	%s = make(map[%s]%s, len(%s))
-	for %s, %s := range %s {
-`, source, sink, kkind, vkind, source, key, val, source)
+		ifScope := sc.inner()
+		loopScope := ifScope.inner()
+		key, val := loopScope.declare(indexed("k", depth)), loopScope.declare(indexed("v", depth))
 
+		bodyScope := loopScope.inner()
 		ksink, vsink := key, val
+		copyKSink := bodyScope.declare(exprIdent(sink) + "_" + key)
+		copyVSink := bodyScope.declare(exprIdent(sink) + "_" + val)
 
-		var b bytes.Buffer
+		kb := e.emit(ident(key), ident(copyKSink), n.Key, bodyScope, depth)
+		vb := e.emit(ident(val), ident(copyVSink), n.Elem, bodyScope, depth)
 
-		if !skipKey {
-			copyKSink := selToIdent(sink) + "_" + key
-			g.walkType(key, copyKSink, x, v.Key(), &b, skips, generating, depth)
-
-			if b.Len() > 0 {
-				ksink = copyKSink
-				fmt.Fprintf(w, "var %s %s\n", ksink, kkind)
-				b.WriteTo(w)
-			}
+		// maps.Clone keeps nil maps nil, like the nil check below.
+		if len(kb) == 0 && len(vb) == 0 && g.clone {
+			return []ast.Stmt{assign(sink, call(selector(ident(g.importPath("maps")), "Clone"), source))}
 		}
 
-		b.Reset()
-
-		if !skipValue {
-			copyVSink := selToIdent(sink) + "_" + val
-			g.walkType(val, copyVSink, x, v.Elem(), &b, skips, generating, depth)
+		kkind := g.typeExpr(v.Key(), x)
+		vkind := g.typeExpr(v.Elem(), x)
 
-			if b.Len() > 0 {
-				vsink = copyVSink
-				fmt.Fprintf(w, "var %s %s\n", vsink, vkind)
-				b.WriteTo(w)
-			}
+		var body []ast.Stmt
+		if len(kb) > 0 {
+			ksink = copyKSink
+			body = append(body, varDecl(ksink, kkind, assigned(ident(key), n.Key)))
+			body = append(body, kb...)
+		}
+		if len(vb) > 0 {
+			vsink = copyVSink
+			body = append(body, varDecl(vsink, vkind, assigned(ident(val), n.Elem)))
+			body = append(body, vb...)
 		}
+		body = append(body, assign(index(sink, ksink), ident(vsink)))
+
+		return []ast.Stmt{ifNotNil(source,
+			assign(sink, call(ident("make"), &ast.MapType{Key: kkind, Value: vkind}, call(ident("len"), source))),
+			&ast.RangeStmt{
+				Key:   ident(key),
+				Value: ident(val),
+				Tok:   token.DEFINE,
+				X:     source,
+				Body:  &ast.BlockStmt{List: body},
+			},
+		)}
+	default:
+		return nil
+	}
+}
 
-		fmt.Fprintf(w, "%s[%s] = %s", sink, ksink, vsink)
+// deref returns the values pointed to by the pointers source and sink, as
+// the plan of the pointed to value copies them. Fields and elements of
+// structs and arrays are selected through the pointers.
+func deref(source, sink ast.Expr, elem Plan) (ast.Expr, ast.Expr) {
+	switch elem.(type) {
+	case *StructCopy, *ArrayCopy:
+		return source, sink
+	default:
+		return &ast.StarExpr{X: source}, &ast.StarExpr{X: sink}
+	}
+}
 
-		fmt.Fprintf(w, "}\n}\n")
+// assigned returns v if the plan copies the members of a value that holds
+// an assignment of it, as the ones of structs and arrays, and nil otherwise.
+func assigned(v ast.Expr, plan Plan) ast.Expr {
+	switch plan.(type) {
+	case *StructCopy, *ArrayCopy:
+		return v
+	default:
+		return nil
 	}
 }
 
@@ -386,102 +680,75 @@ func (g Generator) hasDeepCopy(v methoder, generating []object) (hasMethod, isPo
 			continue
 		}
 
-		sig, ok := m.Type().(*types.Signature)
-		if !ok {
-			continue
-		}
-
-		if sig.Params().Len() != 0 || sig.Results().Len() != 1 {
-			continue
-		}
-
-		ret := sig.Results().At(0)
-		retType, retPointer := reducePointer(ret.Type())
-		sigType, _ := reducePointer(sig.Recv().Type())
-
-		if !types.Identical(retType, sigType) {
-			return false, false
-		}
-
-		return true, retPointer
+		return IsDeepCopyMethod(m, g.methodName)
 	}
 
 	return false, false
 }
 
-func (g Generator) reuseDeepCopy(source, sink string, v methoder, pointer bool, generating []object, w io.Writer) bool {
-	hasMethod, isPointer := g.hasDeepCopy(v, generating)
+// IsDeepCopyMethod reports whether m is a deep copy method with the given
+// name: it takes no parameters, and returns a value of its receiver type, or
+// a pointer to it. isPointer reports whether the result is a pointer.
+func IsDeepCopyMethod(m *types.Func, name string) (ok, isPointer bool) {
+	if m.Name() != name {
+		return false, false
+	}
 
-	if hasMethod {
-		if pointer == isPointer {
-			fmt.Fprintf(w, "%s = %s.%s()\n", sink, source, g.methodName)
-		} else if pointer {
-			fmt.Fprintf(w, `retV := %s.%s()
-	%s = &retV
-`, source, g.methodName, sink)
-		} else {
-			fmt.Fprintf(w, `{
-	retV := %s.%s()
-	%s = *retV
-}
-`, source, g.methodName, sink)
-		}
+	sig, _ := m.Type().(*types.Signature)
+	if sig == nil || sig.Recv() == nil || sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false, false
 	}
 
-	return hasMethod
-}
+	retType, retPointer := reducePointer(sig.Results().At(0).Type())
+	sigType, _ := reducePointer(sig.Recv().Type())
 
-func locateType(kind string, p *packages.Package) (object, error) {
-	for _, t := range p.TypesInfo.Defs {
-		if t == nil {
-			continue
-		}
-		m := exprFilter(t.Type(), kind, p.Name)
-		if m == nil {
-			continue
-		}
-
-		return m, nil
+	if !types.Identical(retType, sigType) {
+		return false, false
 	}
 
-	return nil, errors.New("type not found")
+	return true, retPointer
 }
 
-func reducePointer(typ types.Type) (types.Type, bool) {
-	if pointer, ok := typ.(pointer); ok {
-		return pointer.Elem(), true
+// reuse returns the call of the deep copy method of the plan, assigning its
+// result to sink. Its result is declared in sc when converted.
+func (e emitter) reuse(source, sink ast.Expr, n *MethodReuse, sc *scope) []ast.Stmt {
+	result := call(selector(source, n.Method))
+	if n.Pointer == n.Result {
+		return []ast.Stmt{assign(sink, result)}
 	}
-	return typ, false
-}
 
-func objFromType(typ types.Type) object {
-	typ, _ = reducePointer(typ)
-
-	m, ok := typ.(object)
-	if !ok {
-		return nil
+	if n.Pointer {
+		retV := sc.declare("retV")
+		return []ast.Stmt{
+			define(retV, result),
+			assign(sink, &ast.UnaryExpr{Op: token.AND, X: ident(retV)}),
+		}
 	}
 
-	return m
+	// retV is scoped to its own block.
+	retV := sc.inner().declare("retV")
+	return []ast.Stmt{&ast.BlockStmt{List: []ast.Stmt{
+		define(retV, result),
+		assign(sink, &ast.StarExpr{X: ident(retV)}),
+	}}}
 }
 
-func exprFilter(t types.Type, sel string, x string) object {
-	m := objFromType(t)
-	if m == nil {
-		return nil
-	}
-
-	obj := m.Obj()
-	if obj.Pkg() == nil || x != obj.Pkg().Name() || sel != obj.Name() {
-		return nil
+func reducePointer(typ types.Type) (types.Type, bool) {
+	if pointer, ok := typ.(pointer); ok {
+		return pointer.Elem(), true
 	}
-
-	return m
+	return typ, false
 }
 
 var importSanitizerRE = regexp.MustCompile(`\W`)
 
 func (g Generator) getElemType(t types.Type, x string) string {
+	// An alias is printed by name, unless it isn't accessible from the
+	// package, being unexported in another one.
+	if a, ok := t.(*types.Alias); ok && !a.Obj().Exported() && a.Obj().Pkg() != nil && a.Obj().Pkg().Name() != x {
+		t = types.Unalias(t)
+	}
+
 	kind := types.TypeString(t, func(p *types.Package) string {
 		name := p.Name()
 		if name != x {
@@ -498,15 +765,34 @@ func (g Generator) getElemType(t types.Type, x string) string {
 	return kind
 }
 
-func selToIdent(sel string) string {
-	sel = strings.ReplaceAll(sel, "]", "")
+// importPath imports the package at path, returning the name it is referred
+// to by, suffixed if needed so that it doesn't collide with another import or
+// a package level declaration.
+func (g Generator) importPath(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+	for {
+		imported, ok := g.imports[name]
+		if ok && imported == path {
+			return name
+		}
+		if !ok && (g.scope == nil || g.scope.Lookup(name) == nil) {
+			g.imports[name] = path
+			return name
+		}
 
-	return strings.Map(func(r rune) rune {
-		switch r {
-		case '[', '.':
-			return '_'
-		default:
-			return r
+		name += "_"
+	}
+}
+
+// local returns name, suffixed if needed so that, declared by the generated
+// code, it doesn't shadow a package level declaration or an import.
+func (g Generator) local(name string) string {
+	for {
+		_, imported := g.imports[name]
+		if !imported && (g.scope == nil || g.scope.Lookup(name) == nil) {
+			return name
 		}
-	}, sel)
+
+		name += "_"
+	}
 }
@@ -1,9 +1,14 @@
 package deepcopy
 
 import (
+	"bytes"
+	"regexp"
+	"sync"
 	"testing"
+	"text/template"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewGenerator(t *testing.T) {
@@ -11,8 +16,6 @@ func TestNewGenerator(t *testing.T) {
 		g := NewGenerator()
 		assert.Equal(t, Generator{
 			methodName: "DeepCopy",
-			imports:    map[string]string{},
-			fns:        [][]byte{},
 		}, g)
 	})
 
@@ -21,8 +24,6 @@ func TestNewGenerator(t *testing.T) {
 		assert.Equal(t, Generator{
 			methodName: "DeepCopy",
 			isPtrRecv:  true,
-			imports:    map[string]string{},
-			fns:        [][]byte{},
 		}, g)
 	})
 
@@ -30,8 +31,6 @@ func TestNewGenerator(t *testing.T) {
 		g := NewGenerator(WithMethodName("FuncDeepCopy"))
 		assert.Equal(t, Generator{
 			methodName: "FuncDeepCopy",
-			imports:    map[string]string{},
-			fns:        [][]byte{},
 		}, g)
 	})
 
@@ -40,8 +39,6 @@ func TestNewGenerator(t *testing.T) {
 		assert.Equal(t, Generator{
 			methodName: "DeepCopy",
 			maxDepth:   15,
-			imports:    map[string]string{},
-			fns:        [][]byte{},
 		}, g)
 	})
 
@@ -54,8 +51,6 @@ func TestNewGenerator(t *testing.T) {
 		assert.Equal(t, Generator{
 			methodName: "DeepCopy",
 			skipLists:  sl,
-			imports:    map[string]string{},
-			fns:        [][]byte{},
 		}, g)
 	})
 
@@ -65,8 +60,91 @@ func TestNewGenerator(t *testing.T) {
 		assert.Equal(t, Generator{
 			methodName: "DeepCopy",
 			buildTags:  bts,
-			imports:    map[string]string{},
-			fns:        [][]byte{},
+		}, g)
+	})
+
+	t.Run("WithMarkers", func(t *testing.T) {
+		g := NewGenerator(WithMarkers(true))
+		assert.Equal(t, Generator{
+			methodName: "DeepCopy",
+			markers:    true,
+		}, g)
+	})
+
+	t.Run("WithGoVersion", func(t *testing.T) {
+		g := NewGenerator(WithGoVersion("1.21"))
+		assert.Equal(t, Generator{
+			methodName: "DeepCopy",
+			goVersion:  "1.21",
+		}, g)
+	})
+
+	t.Run("WithAllTypes", func(t *testing.T) {
+		g := NewGenerator(WithAllTypes(true), WithExcludedTypes([]string{"Foo"}))
+		assert.Equal(t, Generator{
+			methodName:    "DeepCopy",
+			all:           true,
+			excludedTypes: []string{"Foo"},
+		}, g)
+	})
+
+	t.Run("WithTypePattern", func(t *testing.T) {
+		re := regexp.MustCompile("Req$")
+		g := NewGenerator(WithTypePattern(re))
+		assert.Equal(t, Generator{
+			methodName:  "DeepCopy",
+			typePattern: re,
+		}, g)
+	})
+
+	t.Run("WithEqual", func(t *testing.T) {
+		g := NewGenerator(WithEqual(true), WithNilEqualsEmpty(true))
+		assert.Equal(t, Generator{
+			methodName:     "DeepCopy",
+			equal:          true,
+			nilEqualsEmpty: true,
+		}, g)
+	})
+
+	t.Run("WithDiff", func(t *testing.T) {
+		g := NewGenerator(WithDiff(true))
+		assert.Equal(t, Generator{
+			methodName: "DeepCopy",
+			diff:       true,
+		}, g)
+	})
+
+	t.Run("WithSize", func(t *testing.T) {
+		g := NewGenerator(WithSize(true))
+		assert.Equal(t, Generator{
+			methodName: "DeepCopy",
+			size:       true,
+		}, g)
+	})
+
+	t.Run("WithMerge", func(t *testing.T) {
+		g := NewGenerator(WithMerge(true))
+		assert.Equal(t, Generator{
+			methodName: "DeepCopy",
+			merge:      true,
+		}, g)
+	})
+
+	t.Run("WithConversions", func(t *testing.T) {
+		convs := []Conversion{{From: "domain.Order", To: "api.Order"}}
+		g := NewGenerator(WithConversions(convs))
+		assert.Equal(t, Generator{
+			methodName:  "DeepCopy",
+			conversions: convs,
+		}, g)
+	})
+
+	t.Run("WithHeader", func(t *testing.T) {
+		tmpl := template.Must(template.New("header").Parse("Copyright ACME"))
+		g := NewGenerator(WithHeader(tmpl))
+		assert.Equal(t, Generator{
+			methodName: "DeepCopy",
+			header:     tmpl,
 		}, g)
 	})
 
@@ -78,8 +156,106 @@ func TestNewGenerator(t *testing.T) {
 		assert.Equal(t, Generator{
 			isPtrRecv:  true,
 			methodName: "FuncDeepCopy",
-			imports:    map[string]string{},
-			fns:        [][]byte{},
 		}, g)
 	})
 }
+
+func TestGenerateMergeErrors(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata/merge")
+	require.NoError(t, err)
+
+	g := NewGenerator(WithMerge(true))
+	tests := []struct {
+		kind    string
+		wantErr string
+	}{
+		{kind: "UnknownPolicy", wantErr: `generating merge method of UnknownPolicy: field Name: unknown merge policy "keep", want "replace", "append" or "-"`},
+		{kind: "AppendToMap", wantErr: `generating merge method of AppendToMap: field Env: merge policy "append" applies to slices only`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := g.Generate(&buf, []string{tt.kind}, pkgs[0])
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestGenerateConcurrently(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata/shadow")
+	require.NoError(t, err)
+
+	// Generating ShadowedImports imports packages, which must not leak into
+	// the other calls.
+	g := NewGenerator(WithGoVersion("1.21"))
+	kinds := []string{"ShadowedImports", "Shadowed", "ShadowedImports", "Shadowed"}
+
+	want := make([]string, len(kinds))
+	for i, kind := range kinds {
+		var buf bytes.Buffer
+		err := g.Generate(&buf, []string{kind}, pkgs[0])
+		require.NoError(t, err)
+		want[i] = buf.String()
+	}
+
+	got := make([]string, len(kinds))
+	var wg sync.WaitGroup
+	for i, kind := range kinds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var buf bytes.Buffer
+			err := g.Generate(&buf, []string{kind}, pkgs[0])
+			assert.NoError(t, err)
+			got[i] = buf.String()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, want, got)
+}
+
+func TestGenerateWarnings(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata")
+	require.NoError(t, err)
+
+	// The warnings are returned in the order the members are generated in,
+	// rather than logged.
+	g := NewGenerator(WithMaxDepth(2))
+	var buf bytes.Buffer
+	warnings, err := g.GenerateWithWarnings(&buf, []string{"Depth1"}, pkgs[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"reached max depth 2. stop recursion at Depth1.a1",
+		"reached max depth 2. stop recursion at Depth1.a2",
+	}, warnings)
+}
+
+func TestGeneratedTypes(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata/selection")
+	require.NoError(t, err)
+
+	// The requested types come first, followed by the selected ones, which
+	// leave out ListReq and its hand-written method.
+	g := NewGenerator(WithTypePattern(regexp.MustCompile("Req$")))
+	got, err := g.GeneratedTypes([]string{"Server"}, pkgs[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Server", "CreateReq"}, got)
+
+	_, err = g.GeneratedTypes([]string{"Missing"}, pkgs[0])
+	assert.ErrorContains(t, err, `locating type "Missing"`)
+}
+
+func TestGenerateEqualPastMaxDepth(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata")
+	require.NoError(t, err)
+
+	// Past the max depth, the members are compared by reflection, rather
+	// than taken as equal.
+	g := NewGenerator(WithMaxDepth(2), WithEqual(true))
+	var buf bytes.Buffer
+	err = g.Generate(&buf, []string{"Depth1"}, pkgs[0])
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "if o.a1 != nil {\n\t\tif !reflect.DeepEqual(*o.a1, *other.a1) {\n\t\t\treturn false")
+}
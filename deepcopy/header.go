@@ -0,0 +1,103 @@
+package deepcopy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// HeaderData is what the header template is executed with.
+type HeaderData struct {
+	// Package is the name of the generated package.
+	Package string
+	// Types are the names of the types generated, in order.
+	Types []string
+	// Version is the version of the main module of the binary generating
+	// the file, or (devel) when it was built from a work tree.
+	Version string
+}
+
+// WithHeader is an option to write the banner rendered by the template, as in
+// a license, at the top of the generated files. The template is executed with
+// a HeaderData. Lines of the banner that are not already line comments are
+// commented out, and the generated code marker is always written after it.
+func WithHeader(t *template.Template) GeneratorOption {
+	return func(g *Generator) {
+		g.header = t
+	}
+}
+
+// writeHeader writes the banner, the generated code marker, the package clause
+// and the build constraints of a file generating the types.
+func (g Generator) writeHeader(w io.Writer, p *packages.Package, objs []object) error {
+	if g.header != nil {
+		banner, err := g.renderHeader(p, objs)
+		if err != nil {
+			return err
+		}
+		if banner != "" {
+			fmt.Fprintf(w, "%s\n", banner)
+		}
+	}
+
+	fmt.Fprintf(w, "// Code generated by deep-copy %s; DO NOT EDIT.\n\npackage %s\n\n", strings.Join(os.Args[1:], " "), p.Name)
+
+	for _, tag := range g.buildTags {
+		fmt.Fprintf(w, "//go:build %s\n// +build %s\n", tag, tag)
+	}
+
+	return nil
+}
+
+// renderHeader executes the header template for the types of the package,
+// returning the banner as line comments followed by a blank line, which keeps
+// it from being taken as the package doc.
+func (g Generator) renderHeader(p *packages.Package, objs []object) (string, error) {
+	data := HeaderData{Package: p.Name, Version: toolVersion()}
+	for _, obj := range objs {
+		data.Types = append(data.Types, obj.Obj().Name())
+	}
+
+	var b bytes.Buffer
+	if err := g.header.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("executing header template: %v", err)
+	}
+
+	text := strings.TrimRight(b.String(), " \t\r\n")
+	if text == "" {
+		return "", nil
+	}
+
+	var banner strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case strings.HasPrefix(line, "//"):
+			banner.WriteString(line)
+		case line == "":
+			banner.WriteString("//")
+		default:
+			banner.WriteString("// " + line)
+		}
+		banner.WriteString("\n")
+	}
+
+	return banner.String(), nil
+}
+
+// toolVersion returns the version of the main module of the running binary,
+// which is deep-copy itself unless the generator is used as a library.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path == "" || info.Main.Version == "" {
+		return "(devel)"
+	}
+
+	return info.Main.Version
+}
@@ -0,0 +1,54 @@
+package deepcopy
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateHeader(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata")
+	require.NoError(t, err)
+
+	tmpl := template.Must(template.New("header").Parse(`Copyright ACME Corp.
+
+{{.Package}}: {{range $i, $t := .Types}}{{if $i}}, {{end}}{{$t}}{{end}} by deep-copy {{.Version}}
+// Licensed under the Apache License, Version 2.0.
+`))
+
+	g := NewGenerator(WithHeader(tmpl), WithBuildTags([]string{"linux"}))
+	var buf bytes.Buffer
+	err = g.Generate(&buf, []string{"Foo", "Bar"}, pkgs[0])
+	require.NoError(t, err)
+
+	banner := "// Copyright ACME Corp.\n" +
+		"//\n" +
+		"// testdata: Foo, Bar by deep-copy " + toolVersion() + "\n" +
+		"// Licensed under the Apache License, Version 2.0.\n" +
+		"\n"
+	require.True(t, strings.HasPrefix(buf.String(), banner), "got:\n%s", buf.String())
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", buf.Bytes(), parser.ParseComments|parser.PackageClauseOnly)
+	require.NoError(t, err)
+	assert.True(t, ast.IsGenerated(f), "the generated code marker is kept")
+	assert.Nil(t, f.Doc, "the banner is not the package doc")
+}
+
+func TestGenerateHeaderError(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata")
+	require.NoError(t, err)
+
+	tmpl := template.Must(template.New("header").Parse("{{.License}}"))
+
+	var buf bytes.Buffer
+	err = NewGenerator(WithHeader(tmpl)).Generate(&buf, []string{"Foo"}, pkgs[0])
+	assert.ErrorContains(t, err, "executing header template: ")
+	assert.ErrorContains(t, err, "can't evaluate field License")
+}
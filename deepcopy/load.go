@@ -0,0 +1,270 @@
+package deepcopy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// LoadConfig configures how Load loads packages.
+type LoadConfig struct {
+	// Tests loads the test variants of the packages, see TestVariant.
+	Tests bool
+
+	// BuildTags, GOOS and GOARCH select the files of the packages to load,
+	// instead of the ones of the host platform without tags.
+	BuildTags []string
+	GOOS      string
+	GOARCH    string
+
+	// Overlay maps absolute file paths to the contents loaded instead of the
+	// files on disk, which need not exist, such as unsaved editor buffers or
+	// freshly generated sources.
+	Overlay map[string][]byte
+
+	// Exclude lists files, typically the previous output of the generator,
+	// whose declarations are left out when they were generated, so that a
+	// stale output does not break the package it is regenerated from.
+	Exclude []string
+
+	// ExportData type-checks only the packages themselves, importing their
+	// dependencies from the export data of the build system instead of
+	// type-checking them from source. The dependencies are then loaded
+	// without syntax nor types.
+	ExportData bool
+}
+
+// Load loads the packages matching the patterns, along with the syntax and
+// type information Generate needs.
+func Load(cfg LoadConfig, patterns ...string) ([]*packages.Package, error) {
+	overlay, err := cfg.overlay()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ExportData {
+		return loadExportData(cfg, overlay, patterns...)
+	}
+
+	return packages.Load(&packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedImports | packages.NeedModule,
+		Tests:      cfg.Tests,
+		BuildFlags: cfg.buildFlags(),
+		Env:        cfg.env(),
+		Overlay:    overlay,
+	}, patterns...)
+}
+
+// Dirs returns the directories of the packages matching the patterns, sorted,
+// without loading their types. It locates the files generated next to the
+// packages, so that they can be excluded before loading the packages.
+func Dirs(cfg LoadConfig, patterns ...string) ([]string, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles,
+		Tests:      cfg.Tests,
+		BuildFlags: cfg.buildFlags(),
+		Env:        cfg.env(),
+		Overlay:    cfg.Overlay,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, p := range pkgs {
+		if len(p.GoFiles) > 0 {
+			dirs = append(dirs, filepath.Dir(p.GoFiles[0]))
+		}
+	}
+	slices.Sort(dirs)
+
+	return slices.Compact(dirs), nil
+}
+
+// Files returns the Go files of the packages matching the patterns, sorted,
+// by import path, without loading their types. The files of the test
+// variants of a package are listed with the package, while the test binaries
+// are left out.
+func Files(cfg LoadConfig, patterns ...string) (map[string][]string, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:       packages.NeedName | packages.NeedFiles,
+		Tests:      cfg.Tests,
+		BuildFlags: cfg.buildFlags(),
+		Env:        cfg.env(),
+		Overlay:    cfg.Overlay,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]string{}
+	for _, p := range pkgs {
+		if strings.HasSuffix(p.ID, ".test") {
+			continue
+		}
+		files[p.PkgPath] = append(files[p.PkgPath], p.GoFiles...)
+	}
+	for path, fs := range files {
+		slices.Sort(fs)
+		files[path] = slices.Compact(fs)
+	}
+
+	return files, nil
+}
+
+// overlay returns the overlay of the build system: the configured one, where
+// every excluded generated file is reduced to its package clause.
+func (cfg LoadConfig) overlay() (map[string][]byte, error) {
+	if len(cfg.Exclude) == 0 {
+		return cfg.Overlay, nil
+	}
+
+	overlay := maps.Clone(cfg.Overlay)
+	for _, path := range cfg.Exclude {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+
+		src, ok := cfg.Overlay[abs]
+		if !ok {
+			src, err = os.ReadFile(abs)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading excluded file: %w", err)
+			}
+		}
+
+		if len(bytes.TrimSpace(src)) == 0 {
+			// An empty file, such as an output file created before
+			// generating, gets the package clause of its directory.
+			if name := dirPackage(filepath.Dir(abs), cfg.Exclude); name != "" {
+				if overlay == nil {
+					overlay = map[string][]byte{}
+				}
+				overlay[abs] = []byte("package " + name + "\n")
+			}
+			continue
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), abs, src, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || !ast.IsGenerated(f) {
+			// Files that aren't Go or were not generated are left alone, the
+			// build system reports their errors.
+			continue
+		}
+
+		if overlay == nil {
+			overlay = map[string][]byte{}
+		}
+		// Keeping the comments above the package clause keeps the build
+		// constraints of the file.
+		end := int(f.Name.End() - f.FileStart)
+		overlay[abs] = append(src[:end:end], '\n')
+	}
+
+	return overlay, nil
+}
+
+// buildFlags returns the build flags passed to the build system.
+func (cfg LoadConfig) buildFlags() []string {
+	if len(cfg.BuildTags) == 0 {
+		return nil
+	}
+
+	return []string{"-tags=" + strings.Join(cfg.BuildTags, ",")}
+}
+
+// env returns the environment of the build system, or nil to inherit the one
+// of the process.
+func (cfg LoadConfig) env() []string {
+	if cfg.GOOS == "" && cfg.GOARCH == "" {
+		return nil
+	}
+
+	env := os.Environ()
+	if cfg.GOOS != "" {
+		env = append(env, "GOOS="+cfg.GOOS)
+	}
+	if cfg.GOARCH != "" {
+		env = append(env, "GOARCH="+cfg.GOARCH)
+	}
+
+	return env
+}
+
+// dirPackage returns the name of the package of the non-test Go files of the
+// directory, leaving out the excluded ones, or "" if there is none.
+func dirPackage(dir string, exclude []string) string {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return ""
+	}
+
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") || slices.ContainsFunc(exclude, func(path string) bool {
+			abs, err := filepath.Abs(path)
+			return err == nil && abs == name
+		}) {
+			continue
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.PackageClauseOnly)
+		if err == nil {
+			return f.Name.Name
+		}
+	}
+
+	return ""
+}
+
+// ReadOverlay reads an overlay file in the format of go build -overlay, a JSON
+// object whose Replace field maps file paths to the paths of the files to read
+// instead. Relative paths are relative to the current directory. The contents
+// are returned by absolute path, as LoadConfig.Overlay expects.
+func ReadOverlay(path string) (map[string][]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var overlay struct {
+		Replace map[string]string
+	}
+	if err := json.Unmarshal(b, &overlay); err != nil {
+		return nil, fmt.Errorf("parsing overlay %s: %w", path, err)
+	}
+
+	contents := make(map[string][]byte, len(overlay.Replace))
+	for from, to := range overlay.Replace {
+		if to == "" {
+			return nil, fmt.Errorf("overlay %s: deleting %s is not supported", path, from)
+		}
+
+		abs, err := filepath.Abs(from)
+		if err != nil {
+			return nil, err
+		}
+
+		contents[abs], err = os.ReadFile(to)
+		if err != nil {
+			return nil, fmt.Errorf("overlay %s: %w", path, err)
+		}
+	}
+
+	return contents, nil
+}
@@ -0,0 +1,109 @@
+package deepcopy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOverlay(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o666))
+		return path
+	}
+
+	buffer := write("buffer.go", "package p\n")
+
+	t.Run("replace", func(t *testing.T) {
+		path := write("overlay.json", `{"Replace": {"p/a.go": "`+filepath.ToSlash(buffer)+`"}}`)
+
+		overlay, err := ReadOverlay(path)
+		require.NoError(t, err)
+
+		abs, err := filepath.Abs("p/a.go")
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{abs: []byte("package p\n")}, overlay)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		path := write("delete.json", `{"Replace": {"p/a.go": ""}}`)
+
+		_, err := ReadOverlay(path)
+		assert.EqualError(t, err, "overlay "+path+": deleting p/a.go is not supported")
+	})
+
+	t.Run("missing replacement", func(t *testing.T) {
+		path := write("missing.json", `{"Replace": {"p/a.go": "missing.go"}}`)
+
+		_, err := ReadOverlay(path)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		path := write("invalid.json", `{"Replace": [`)
+
+		_, err := ReadOverlay(path)
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadConfigOverlay(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o666))
+		return path
+	}
+
+	generated := write("a_deepcopy.go", "// Code generated by deep-copy; DO NOT EDIT.\n\n//go:build linux\n\npackage p\n\nfunc (o T) DeepCopy() T { return o }\n")
+	handWritten := write("b.go", "package p\n\nfunc (o U) DeepCopy() U { return o }\n")
+	buffer := filepath.Join(dir, "c_deepcopy.go")
+	empty := write("d_deepcopy.go", "")
+	write("e.go", "package p\n")
+
+	cfg := LoadConfig{
+		Overlay: map[string][]byte{buffer: []byte("// Code generated by deep-copy; DO NOT EDIT.\n\npackage p\n\nvar v = missing\n")},
+		Exclude: []string{generated, handWritten, buffer, empty, filepath.Join(dir, "missing.go")},
+	}
+
+	overlay, err := cfg.overlay()
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		generated: []byte("// Code generated by deep-copy; DO NOT EDIT.\n\n//go:build linux\n\npackage p\n"),
+		buffer:    []byte("// Code generated by deep-copy; DO NOT EDIT.\n\npackage p\n"),
+		empty:     []byte("package p\n"),
+	}, overlay)
+	assert.Contains(t, string(cfg.Overlay[buffer]), "missing", "the configured overlay is left alone")
+}
+
+func TestFingerprint(t *testing.T) {
+	dir, err := filepath.Abs("../testdata/shadow")
+	require.NoError(t, err)
+	file := filepath.Join(dir, "shadow.go")
+
+	src, err := os.ReadFile(file)
+	require.NoError(t, err)
+	edited := append(append([]byte(nil), src...), "\ntype Added struct{}\n"...)
+
+	fingerprint := func(cfg LoadConfig) string {
+		t.Helper()
+		fp, err := Fingerprint(cfg, dir)
+		require.NoError(t, err)
+		return fp
+	}
+
+	base := fingerprint(LoadConfig{})
+	assert.Equal(t, base, fingerprint(LoadConfig{}), "unchanged package")
+	assert.NotEqual(t, base, fingerprint(LoadConfig{Overlay: map[string][]byte{file: edited}}), "edited file")
+	assert.NotEqual(t, base, fingerprint(LoadConfig{BuildTags: []string{"enterprise"}}), "build tags")
+	assert.Equal(t,
+		fingerprint(LoadConfig{Exclude: []string{file}}),
+		fingerprint(LoadConfig{Exclude: []string{file}, Overlay: map[string][]byte{file: edited}}),
+		"edited excluded file",
+	)
+}
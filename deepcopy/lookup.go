@@ -0,0 +1,212 @@
+package deepcopy
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// locateType returns the named type kind of the package, on which the deep
+// copy method is declared. Methods can only be declared on a type of the
+// package, so kind must name a non-interface type of p, or an alias of one.
+func locateType(kind string, p *packages.Package) (object, error) {
+	obj, err := lookupType(kind, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if obj.IsAlias() {
+		return resolveAlias(obj, p)
+	}
+
+	named, ok := obj.Type().(*types.Named)
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("%s is not a named type", kind)
+	case obj.Pkg() != p.Types:
+		return nil, fmt.Errorf("%s is declared in another package", kind)
+	case types.IsInterface(named):
+		return nil, fmt.Errorf("%s is an interface type, not a concrete type", kind)
+	}
+
+	return named, nil
+}
+
+// resolveAlias returns the named type the alias obj stands for. Methods can
+// only be declared on a type of the package, so the alias must stand for a
+// non-generic named type declared in p.
+func resolveAlias(obj *types.TypeName, p *packages.Package) (object, error) {
+	aliased := types.Unalias(obj.Type())
+	kind := types.TypeString(aliased, types.RelativeTo(p.Types))
+
+	named, ok := aliased.(*types.Named)
+	switch {
+	case !ok:
+		return nil, fmt.Errorf("%s is an alias of %s, which is not a named type", obj.Name(), kind)
+	case named.Obj().Pkg() != p.Types:
+		return nil, fmt.Errorf("%s is an alias of %s, which is declared in another package", obj.Name(), kind)
+	case named.TypeArgs().Len() > 0:
+		return nil, fmt.Errorf("%s is an alias of %s, which is an instantiated generic type", obj.Name(), kind)
+	case types.IsInterface(named):
+		return nil, fmt.Errorf("%s is an alias of %s, which is an interface type, not a concrete type", obj.Name(), kind)
+	}
+
+	return named, nil
+}
+
+// lookupType looks kind up in the scope of the package. A kind qualified by
+// an import path, as in "example.com/pkg.Type", must be qualified by the path
+// of p, or by the one of the package an external test package tests.
+func lookupType(kind string, p *packages.Package) (*types.TypeName, error) {
+	name := kind
+	if i := strings.LastIndex(kind, "."); i >= 0 {
+		path := kind[:i]
+		name = kind[i+1:]
+
+		if path != p.PkgPath && path+"_test" != p.PkgPath {
+			return nil, fmt.Errorf("%s is qualified by another package than %s, whose types can't have methods declared in it", kind, p.PkgPath)
+		}
+	}
+
+	switch obj := p.Types.Scope().Lookup(name).(type) {
+	case *types.TypeName:
+		return obj, nil
+	case nil:
+		return nil, typeNotFound(name, p.Types.Scope())
+	default:
+		return nil, fmt.Errorf("type not found: %s is a %s, not a type", name, objectKind(obj))
+	}
+}
+
+// importedPackage returns the package at path, if it is p or one of its
+// dependencies.
+func importedPackage(p *packages.Package, path string) *types.Package {
+	// The import graph of the types is walked rather than the one of the
+	// loaded packages, which only holds stubs when the dependencies are
+	// loaded from export data.
+	return findPackage(p.Types, path, map[*types.Package]bool{})
+}
+
+// typeNotFound returns the error of a type name not found in scope, suggesting
+// the closest type names.
+func typeNotFound(name string, scope *types.Scope) error {
+	suggestions := closestTypeNames(name, scope)
+	if len(suggestions) == 0 {
+		return errors.New("type not found")
+	}
+
+	for i, s := range suggestions {
+		suggestions[i] = "`" + s + "`"
+	}
+
+	return fmt.Errorf("type not found, did you mean %s?", strings.Join(suggestions, " or "))
+}
+
+// maxSuggestions is the maximum number of type names typeNotFound suggests.
+const maxSuggestions = 3
+
+// closestTypeNames returns the type names of the scope closest to name, when
+// a few edits, ignoring case, turn one into the other.
+func closestTypeNames(name string, scope *types.Scope) []string {
+	lower := strings.ToLower(name)
+	threshold := max(2, len(name)/3)
+
+	var closest []string
+	for _, candidate := range scope.Names() {
+		if _, ok := scope.Lookup(candidate).(*types.TypeName); !ok {
+			continue
+		}
+
+		d := editDistance(lower, strings.ToLower(candidate))
+		switch {
+		case d > threshold:
+		case d < threshold:
+			threshold = d
+			closest = append(closest[:0], candidate)
+		default:
+			closest = append(closest, candidate)
+		}
+	}
+
+	sort.Strings(closest)
+	if len(closest) > maxSuggestions {
+		closest = closest[:maxSuggestions]
+	}
+
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
+
+// objectKind describes the kind of a non-type object.
+func objectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Var:
+		return "variable"
+	case *types.Const:
+		return "constant"
+	case *types.Func:
+		return "function"
+	default:
+		return "declaration"
+	}
+}
+
+// TestVariant returns the test variant of the loaded packages declaring the
+// types: the package compiled with its internal _test.go files, or else its
+// external _test package. The packages must be loaded with tests.
+func (g Generator) TestVariant(pkgs []*packages.Package, types []string) (*packages.Package, error) {
+	var variants []*packages.Package
+	for _, p := range pkgs {
+		if strings.HasSuffix(p.ID, ".test]") {
+			variants = append(variants, p)
+		}
+	}
+	if len(variants) == 0 {
+		return nil, errors.New("no test files found")
+	}
+
+	// The internal variant goes first, as it also declares the types of the
+	// package itself.
+	sort.SliceStable(variants, func(i, j int) bool {
+		return !strings.HasSuffix(variants[i].Name, "_test") && strings.HasSuffix(variants[j].Name, "_test")
+	})
+
+	var firstErr error
+	for _, p := range variants {
+		_, _, err := g.locateTypes(types, p)
+		if err == nil {
+			return p, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
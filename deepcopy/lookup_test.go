@@ -0,0 +1,105 @@
+package deepcopy
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "order", b: "", want: 5},
+		{a: "order", b: "order", want: 0},
+		{a: "orderitm", b: "orderitem", want: 1},
+		{a: "ordre", b: "order", want: 2},
+		{a: "kitten", b: "sitting", want: 3},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, editDistance(tt.a, tt.b), "editDistance(%q, %q)", tt.a, tt.b)
+	}
+}
+
+func TestClosestTypeNames(t *testing.T) {
+	pkg := types.NewPackage("example.com/orders", "orders")
+	scope := pkg.Scope()
+	for _, name := range []string{"Order", "OrderItem", "OrderItems", "Customer"} {
+		scope.Insert(types.NewTypeName(0, pkg, name, nil))
+	}
+	scope.Insert(types.NewVar(0, pkg, "Orders", types.Typ[types.Int]))
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "OrderItm", want: []string{"OrderItem"}},
+		{name: "orderitem2", want: []string{"OrderItem", "OrderItems"}},
+		{name: "Orders", want: []string{"Order"}},
+		{name: "Costumer", want: []string{"Customer"}},
+		{name: "Invoice", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, closestTypeNames(tt.name, scope))
+		})
+	}
+}
+
+func TestLookupType(t *testing.T) {
+	const (
+		lookupPath = "github.com/globusdigital/deep-copy/testdata/lookup"
+		ordersPath = "github.com/globusdigital/deep-copy/testdata/testvariants"
+	)
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+		Tests: true,
+	}, "../testdata/lookup", "../testdata/testvariants")
+	require.NoError(t, err)
+
+	byID := map[string]*packages.Package{}
+	for _, p := range pkgs {
+		byID[p.ID] = p
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		kind    string
+		wantErr string
+	}{
+		{name: "unqualified", id: lookupPath, kind: "Order"},
+		{name: "qualified by the package", id: lookupPath, kind: lookupPath + ".Order"},
+		{
+			name:    "qualified by a dependency",
+			id:      lookupPath,
+			kind:    "github.com/globusdigital/deep-copy/testdata/aliases/other.Item",
+			wantErr: "github.com/globusdigital/deep-copy/testdata/aliases/other.Item is qualified by another package than " + lookupPath + ", whose types can't have methods declared in it",
+		},
+		{name: "qualified by the package, internal test variant", id: ordersPath + " [" + ordersPath + ".test]", kind: ordersPath + ".fixture"},
+		{name: "qualified by the tested package, external test variant", id: ordersPath + "_test [" + ordersPath + ".test]", kind: ordersPath + ".Case"},
+		{name: "qualified by the package, missing type", id: lookupPath, kind: lookupPath + ".Ordr", wantErr: "type not found, did you mean `Order`?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := byID[tt.id]
+			require.NotNil(t, p, "package %s not loaded", tt.id)
+
+			obj, err := lookupType(tt.kind, p)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, p.Types, obj.Pkg())
+		})
+	}
+}
@@ -0,0 +1,362 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	markerPrefix          = "+k8s:deepcopy-gen"
+	markerInterfacePrefix = markerPrefix + ":interfaces="
+)
+
+// typeMarkers holds the deepcopy-gen markers found in the doc comment of a
+// type declaration.
+type typeMarkers struct {
+	// enabled is nil if the type has no +k8s:deepcopy-gen=true|false marker.
+	enabled    *bool
+	interfaces []string
+}
+
+// markedType is a type selected for generation by a deepcopy-gen marker.
+type markedType struct {
+	name    string
+	markers typeMarkers
+}
+
+// parseMarkers extracts the deepcopy-gen markers from the comment groups. The
+// markers it does not support, as +k8s:deepcopy-gen:nonpointer-interfaces,
+// are returned as ignored, so that the packages annotated for deepcopy-gen
+// can be generated all the same.
+func parseMarkers(groups ...*ast.CommentGroup) (m typeMarkers, pkg bool, ignored []*ast.Comment) {
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+
+		for _, c := range group.List {
+			line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if !strings.HasPrefix(line, markerPrefix) {
+				continue
+			}
+
+			switch {
+			case strings.HasPrefix(line, markerInterfacePrefix):
+				for _, iface := range strings.Split(strings.TrimPrefix(line, markerInterfacePrefix), ",") {
+					if iface = strings.TrimSpace(iface); iface != "" {
+						m.interfaces = append(m.interfaces, iface)
+					}
+				}
+			case line == markerPrefix+"=package", line == markerPrefix+"=package,register":
+				// Registering the types is up to register-gen.
+				pkg = true
+			case line == markerPrefix+"=true":
+				enabled := true
+				m.enabled = &enabled
+			case line == markerPrefix+"=false":
+				enabled := false
+				m.enabled = &enabled
+			default:
+				ignored = append(ignored, c)
+			}
+		}
+	}
+
+	return m, pkg, ignored
+}
+
+// warnIgnored warns about the markers parseMarkers ignores.
+func (g Generator) warnIgnored(p *packages.Package, ignored []*ast.Comment) {
+	for _, c := range ignored {
+		g.warn("%s: ignoring unsupported marker %q", p.Fset.Position(c.Pos()), strings.TrimSpace(strings.TrimPrefix(c.Text, "//")))
+	}
+}
+
+// packageMarked reports whether any file of the package carries the
+// +k8s:deepcopy-gen=package marker above its package clause.
+func (g Generator) packageMarked(p *packages.Package) bool {
+	var marked bool
+	for _, f := range p.Syntax {
+		for _, group := range f.Comments {
+			if group.End() >= f.Package {
+				break
+			}
+
+			_, pkg, ignored := parseMarkers(group)
+			g.warnIgnored(p, ignored)
+			marked = marked || pkg
+		}
+	}
+
+	return marked
+}
+
+// markedTypes returns the markers of every type of the package, along with
+// the names, in declaration order, of the types the deepcopy-gen markers
+// select for generation. Interfaces, generic types, aliases, excluded types
+// and types that already have a hand-written method are never selected.
+func (g Generator) markedTypes(p *packages.Package) (map[string]typeMarkers, []string) {
+	pkgEnabled := g.packageMarked(p)
+
+	markers := map[string]typeMarkers{}
+	var selected []string
+	for _, f := range p.Syntax {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+
+				groups := []*ast.CommentGroup{ts.Doc}
+				if !gen.Lparen.IsValid() {
+					groups = append(groups, gen.Doc)
+				}
+
+				m, _, ignored := parseMarkers(groups...)
+				g.warnIgnored(p, ignored)
+				markers[ts.Name.Name] = m
+
+				if m.enabled != nil && !*m.enabled || m.enabled == nil && !pkgEnabled {
+					continue
+				}
+
+				if ts.Assign.IsValid() || ts.TypeParams != nil {
+					continue
+				}
+				if _, ok := ts.Type.(*ast.InterfaceType); ok {
+					continue
+				}
+				if g.isExcluded(ts.Name.Name) || g.hasHandWrittenMethod(p, ts.Name.Name) {
+					continue
+				}
+
+				selected = append(selected, ts.Name.Name)
+			}
+		}
+	}
+
+	return markers, selected
+}
+
+// withMarkedTypes appends the marker selected types of the package to the
+// explicitly requested ones, and returns the markers of every type.
+func (g Generator) withMarkedTypes(kinds []string, p *packages.Package) ([]string, map[string]typeMarkers) {
+	markers, selected := g.markedTypes(p)
+
+	requested := make(map[string]struct{}, len(kinds))
+	for _, kind := range kinds {
+		requested[kind] = struct{}{}
+	}
+
+	kinds = append([]string(nil), kinds...)
+	for _, name := range selected {
+		if _, ok := requested[name]; !ok {
+			kinds = append(kinds, name)
+		}
+	}
+
+	return kinds, markers
+}
+
+// hasHandWrittenMethod reports whether the named type of the package declares
+// the deep copy method, or its Into variant, in a file that was not generated.
+func (g Generator) hasHandWrittenMethod(p *packages.Package, name string) bool {
+	obj, ok := p.Types.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return false
+	}
+
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return false
+	}
+
+	for i := 0; i < named.NumMethods(); i++ {
+		m := named.Method(i)
+		if m.Name() != g.methodName && m.Name() != g.methodName+"Into" {
+			continue
+		}
+
+		if f := fileOf(p, m.Pos()); f != nil && !ast.IsGenerated(f) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fileOf returns the syntax tree of the package file containing pos.
+func fileOf(p *packages.Package, pos token.Pos) *ast.File {
+	for _, f := range p.Syntax {
+		if f.FileStart <= pos && pos <= f.FileEnd {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// lookupInterface resolves a deepcopy-gen interface reference of the form
+// "import/path.Name" in the import graph of the package.
+func lookupInterface(p *packages.Package, ref string) (*types.TypeName, error) {
+	i := strings.LastIndex(ref, ".")
+	if i <= 0 || i == len(ref)-1 {
+		return nil, fmt.Errorf("invalid interface reference %q", ref)
+	}
+	path, name := ref[:i], ref[i+1:]
+
+	pkg := findPackage(p.Types, path, map[*types.Package]bool{})
+	if pkg == nil {
+		return nil, fmt.Errorf("package %q of interface %q is not imported", path, ref)
+	}
+
+	obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok || !types.IsInterface(obj.Type()) {
+		return nil, fmt.Errorf("%q is not an interface type", ref)
+	}
+
+	return obj, nil
+}
+
+func findPackage(pkg *types.Package, path string, seen map[*types.Package]bool) *types.Package {
+	if pkg.Path() == path {
+		return pkg
+	}
+	if seen[pkg] {
+		return nil
+	}
+	seen[pkg] = true
+
+	for _, imp := range pkg.Imports() {
+		if found := findPackage(imp, path, seen); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// generateMarkerFuncs generates the methods of the type deepcopy-gen does: the
+// DeepCopyInto method copying the receiver into its argument, the deep copy
+// method allocating the copy and calling it, and the methods of the
+// interfaces its markers declare.
+func (g Generator) generateMarkerFuncs(p *packages.Package, obj object, m typeMarkers, skips skips, generating []object) ([]ast.Decl, error) {
+	kind := obj.Obj().Name()
+	decls := []ast.Decl{
+		g.generateIntoFunc(p, obj, skips, generating),
+		g.generateIntoCaller(obj),
+	}
+
+	for _, ref := range m.interfaces {
+		iface, err := lookupInterface(p, ref)
+		if err != nil {
+			return nil, fmt.Errorf("type %q: %v", kind, err)
+		}
+		decls = append(decls, g.generateInterfaceFunc(p, obj, iface))
+	}
+
+	return decls, nil
+}
+
+// generateInterfaceFunc builds the method returning a deep copy of the type
+// as the interface, or nil, as the ones of deepcopy-gen do.
+func (g Generator) generateInterfaceFunc(p *packages.Package, obj object, iface *types.TypeName) *ast.FuncDecl {
+	kind := obj.Obj().Name()
+	name := g.methodName + iface.Name()
+	ret := g.typeExpr(iface.Type(), p.Name)
+
+	sc := g.funcScope()
+	source := ident(sc.declare("o"))
+	c := ident(sc.inner().declare("c"))
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %s generates a deep copy of *%s as a %s.", name, kind, types.ExprString(ret))},
+		}},
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{source}, Type: &ast.StarExpr{X: ident(kind)}}}},
+		Name: ident(name),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ret}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Init: define(c.Name, call(selector(source, g.methodName))),
+				Cond: &ast.BinaryExpr{X: c, Op: token.NEQ, Y: ident("nil")},
+				Body: &ast.BlockStmt{List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{c}}}},
+			},
+			&ast.ReturnStmt{Results: []ast.Expr{ident("nil")}},
+		}},
+	}
+}
+
+// generateIntoFunc builds the DeepCopyInto method of the type, which copies
+// the receiver into out, as the one of deepcopy-gen does.
+func (g Generator) generateIntoFunc(p *packages.Package, obj object, skips skips, generating []object) *ast.FuncDecl {
+	kind := obj.Obj().Name()
+
+	sc := g.funcScope()
+	source, sink := ident(sc.declare("o")), ident(sc.declare("out"))
+
+	// The fields of a struct are selected through the pointers, while other
+	// values are copied into the pointed to one.
+	var src, dst ast.Expr = source, sink
+	if _, ok := obj.Underlying().(*types.Struct); !ok {
+		src, dst = &ast.StarExpr{X: source}, &ast.StarExpr{X: sink}
+	}
+
+	body := []ast.Stmt{assign(&ast.StarExpr{X: sink}, &ast.StarExpr{X: source})}
+	e := emitter{g: g, x: p.Name, root: kind}
+	body = append(body, e.emit(src, dst, g.copyPlan(obj, p.Name, skips, generating), sc, 0)...)
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %sInto copies the receiver into %s. %s must be non-nil.", g.methodName, sink.Name, source.Name)},
+		}},
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{source}, Type: &ast.StarExpr{X: ident(kind)}}}},
+		Name: ident(g.methodName + "Into"),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{sink}, Type: &ast.StarExpr{X: ident(kind)}}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
+
+// generateIntoCaller builds the deep copy method of the type, allocating the
+// copy and filling it with DeepCopyInto.
+func (g Generator) generateIntoCaller(obj object) *ast.FuncDecl {
+	kind := obj.Obj().Name()
+
+	sc := g.funcScope()
+	source, out := ident(sc.declare("o")), ident(sc.declare("out"))
+	nilResult := &ast.ReturnStmt{Results: []ast.Expr{ident("nil")}}
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %s generates a deep copy of *%s", g.methodName, kind)},
+		}},
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{source}, Type: &ast.StarExpr{X: ident(kind)}}}},
+		Name: ident(g.methodName),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: &ast.StarExpr{X: ident(kind)}}}},
+		},
+		Body: &ast.BlockStmt{List: []ast.Stmt{
+			&ast.IfStmt{
+				Cond: &ast.BinaryExpr{X: source, Op: token.EQL, Y: ident("nil")},
+				Body: &ast.BlockStmt{List: []ast.Stmt{nilResult}},
+			},
+			define(out.Name, call(ident("new"), ident(kind))),
+			&ast.ExprStmt{X: call(selector(source, g.methodName+"Into"), out)},
+			&ast.ReturnStmt{Results: []ast.Expr{out}},
+		}},
+	}
+}
@@ -0,0 +1,73 @@
+package deepcopy
+
+import (
+	"bytes"
+	"go/ast"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarkers(t *testing.T) {
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name        string
+		lines       []string
+		want        typeMarkers
+		wantPkg     bool
+		wantIgnored []string
+	}{
+		{name: "no markers", lines: []string{"// Foo is a type."}},
+		{name: "package", lines: []string{"// +k8s:deepcopy-gen=package"}, wantPkg: true},
+		{name: "package registered", lines: []string{"// +k8s:deepcopy-gen=package,register"}, wantPkg: true},
+		{name: "enabled", lines: []string{"// +k8s:deepcopy-gen=true"}, want: typeMarkers{enabled: &enabled}},
+		{name: "disabled", lines: []string{"// +k8s:deepcopy-gen=false"}, want: typeMarkers{enabled: &disabled}},
+		{
+			name: "interfaces",
+			lines: []string{
+				"// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object",
+				"// +k8s:deepcopy-gen:interfaces=example.com/a.B,example.com/c.D",
+			},
+			want: typeMarkers{interfaces: []string{"k8s.io/apimachinery/pkg/runtime.Object", "example.com/a.B", "example.com/c.D"}},
+		},
+		{
+			name:        "unsupported",
+			lines:       []string{"// +k8s:deepcopy-gen=maybe", "// +k8s:deepcopy-gen=true", "// +k8s:deepcopy-gen:nonpointer-interfaces=true"},
+			want:        typeMarkers{enabled: &enabled},
+			wantIgnored: []string{"// +k8s:deepcopy-gen=maybe", "// +k8s:deepcopy-gen:nonpointer-interfaces=true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			group := &ast.CommentGroup{}
+			for _, l := range tt.lines {
+				group.List = append(group.List, &ast.Comment{Text: l})
+			}
+
+			got, pkg, ignored := parseMarkers(group)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantPkg, pkg)
+
+			var texts []string
+			for _, c := range ignored {
+				texts = append(texts, c.Text)
+			}
+			assert.Equal(t, tt.wantIgnored, texts)
+		})
+	}
+}
+
+func TestGenerateIgnoredMarkers(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata/k8s/apis/v1")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	warnings, err := NewGenerator(WithMarkers(true)).GenerateWithWarnings(&buf, nil, pkgs[0])
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Regexp(t, `types\.go:9:1: ignoring unsupported marker "\+k8s:deepcopy-gen:nonpointer-interfaces=true"$`, warnings[0])
+	assert.Contains(t, buf.String(), "func (o *Widget) DeepCopyObject() runtime.Object")
+}
@@ -0,0 +1,428 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// mergeMethodName is the name of the generated merge methods, and of the
+// existing ones they call.
+const mergeMethodName = "MergeFrom"
+
+// mergeTag is the struct tag key setting the merge policy of a field.
+const mergeTag = "merge"
+
+// The merge policies of a field, set by its merge tag.
+const (
+	// mergeDefault overwrites the destination with the non-zero members of
+	// the source, merging structs field by field, pointed to structs
+	// recursively, and maps by key.
+	mergeDefault = ""
+	// mergeReplace overwrites the field as a whole when the source is not
+	// zero.
+	mergeReplace = "replace"
+	// mergeAppend appends the elements of a source slice to the field.
+	mergeAppend = "append"
+	// mergeKeep leaves the field untouched.
+	mergeKeep = "-"
+)
+
+// generateMergeFunc builds the MergeFrom method of the type, overlaying the
+// non-zero members of the source value onto the receiver, and deep copying
+// them so that the receiver never aliases the source. The skipped members are
+// left untouched.
+func (g Generator) generateMergeFunc(p *packages.Package, obj object, skips skips, generating []object) (*ast.FuncDecl, error) {
+	kind := obj.Obj().Name()
+
+	sc := g.funcScope()
+	recv, src := ident(sc.declare("o")), ident(sc.declare("src"))
+
+	// The fields of a struct are selected through the receiver.
+	var dst ast.Expr = recv
+	if _, ok := obj.Underlying().(*types.Struct); !ok {
+		dst = &ast.StarExpr{X: recv}
+	}
+
+	m := merger{
+		g:      g,
+		x:      p.Name,
+		copies: g.copyPlanner(p.Name, skips, generating),
+		e:      emitter{g: g, x: p.Name, root: kind},
+	}
+	body, err := m.merge(dst, src, g.mergePlanner(p.Name, skips, generating).plan("", "", obj, 0), sc, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %s overlays the non-zero members of %s onto *%s, deep copying them", mergeMethodName, src.Name, kind)},
+		}},
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{recv}, Type: &ast.StarExpr{X: ident(kind)}}}},
+		Name: ident(mergeMethodName),
+		Type: &ast.FuncType{
+			Params: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{src}, Type: ident(kind)}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}, nil
+}
+
+// mergePlanner returns the planner of the merge methods, which reuse the
+// merge methods of the members.
+func (g Generator) mergePlanner(x string, skips skips, generating []object) planner {
+	return planner{g: g, x: x, skips: skips, find: func(v methoder) (string, bool, bool) {
+		return mergeMethodName, false, g.hasMerge(v, generating)
+	}}
+}
+
+// merger builds the statements of merge methods from their plans. The
+// replaced members are deep copied following their copy plans.
+type merger struct {
+	g      Generator
+	x      string
+	copies planner
+	e      emitter
+}
+
+// merge returns the statements merging src into dst following the plan. The
+// locals are declared in sc. depth follows the one of the plan.
+func (m merger) merge(dst, src ast.Expr, plan Plan, sc *scope, depth int) ([]ast.Stmt, error) {
+	g := m.g
+
+	switch n := plan.(type) {
+	case *Skip:
+		// Past the max depth, members are replaced, sharing what they
+		// reference, as in deep copies.
+		if n.Reason == PastMaxDepth {
+			return m.replace(dst, src, n.Member, sc, depth), nil
+		}
+		return nil, nil
+	case *MethodReuse:
+		if !n.Pointer {
+			return []ast.Stmt{&ast.ExprStmt{X: call(selector(dst, n.Method), src)}}, nil
+		}
+
+		// A nil destination is merged into as a new zero value.
+		elem := n.Type.Underlying().(*types.Pointer).Elem()
+		return []ast.Stmt{ifNotNil(src,
+			ifNil(dst, assign(dst, call(ident("new"), g.typeExpr(elem, m.x)))),
+			&ast.ExprStmt{X: call(selector(dst, n.Method), &ast.StarExpr{X: src})},
+		)}, nil
+	case *StructCopy:
+		if !mergesFields(n) {
+			return m.replace(dst, src, n.Member, sc, depth), nil
+		}
+	case *ArrayCopy, *MapCopy, *PointerCopy:
+	default:
+		return m.replace(dst, src, plan.At(), sc, depth), nil
+	}
+
+	member := plan.At()
+	depth++
+	switch n := plan.(type) {
+	case *StructCopy:
+		st := n.Type.Underlying().(*types.Struct)
+		tags := make(map[string]string, st.NumFields())
+		for i := 0; i < st.NumFields(); i++ {
+			tags[st.Field(i).Name()] = st.Tag(i)
+		}
+
+		var stmts []ast.Stmt
+		for _, f := range n.Fields {
+			if skip, ok := f.Copy.(*Skip); ok && skip.Reason == SkippedBySelector {
+				continue
+			}
+
+			fdst, fsrc := selector(dst, f.Name), selector(src, f.Name)
+			fm := f.Copy.At()
+			switch policy := reflect.StructTag(tags[f.Name]).Get(mergeTag); policy {
+			case mergeDefault:
+				fs, err := m.merge(fdst, fsrc, f.Copy, sc, depth)
+				if err != nil {
+					return nil, err
+				}
+				stmts = append(stmts, fs...)
+			case mergeReplace:
+				stmts = append(stmts, m.replace(fdst, fsrc, fm, sc, depth)...)
+			case mergeAppend:
+				if _, ok := fm.Type.Underlying().(*types.Slice); !ok {
+					return nil, fmt.Errorf("field %s: merge policy %q applies to slices only", fm.Selector, policy)
+				}
+				stmts = append(stmts, m.appendCopy(fdst, fsrc, fm, sc, depth)...)
+			case mergeKeep:
+			default:
+				return nil, fmt.Errorf("field %s: unknown merge policy %q, want %q, %q or %q", fm.Selector, policy, mergeReplace, mergeAppend, mergeKeep)
+			}
+		}
+		return stmts, nil
+	case *ArrayCopy:
+		loopScope := sc.inner()
+		idx := loopScope.declare(indexed("i", depth))
+
+		body, err := m.merge(index(dst, idx), index(src, idx), n.Elem, loopScope.inner(), depth)
+		if err != nil || len(body) == 0 {
+			return nil, err
+		}
+
+		return []ast.Stmt{&ast.RangeStmt{
+			Key:  ident(idx),
+			Tok:  token.DEFINE,
+			X:    src,
+			Body: &ast.BlockStmt{List: body},
+		}}, nil
+	case *MapCopy:
+		if skip, ok := n.Elem.(*Skip); ok && skip.Reason == SkippedBySelector {
+			return m.replace(dst, src, member, sc, depth-1), nil
+		}
+
+		v := n.Type.Underlying().(*types.Map)
+
+		ifScope := sc.inner()
+		loopScope := ifScope.inner()
+		key, val := loopScope.declare(indexed("k", depth)), loopScope.declare(indexed("v", depth))
+
+		// The values are replaced by key, as they can't be merged in
+		// place.
+		bodyScope := loopScope.inner()
+		var body []ast.Stmt
+		var value ast.Expr = ident(val)
+		if cp := m.copies.at(n.Elem.At(), depth); deep(cp) {
+			cpv := bodyScope.declare(exprIdent(dst) + "_" + val)
+			body = m.declareCopy(cpv, ident(val), cp, bodyScope, depth)
+			value = ident(cpv)
+		}
+		body = append(body, assign(&ast.IndexExpr{X: dst, Index: ident(key)}, value))
+
+		return []ast.Stmt{ifNotNil(src,
+			ifNil(dst, assign(dst, call(ident("make"), &ast.MapType{Key: g.typeExpr(v.Key(), m.x), Value: g.typeExpr(v.Elem(), m.x)}, call(ident("len"), src)))),
+			&ast.RangeStmt{
+				Key:   ident(key),
+				Value: ident(val),
+				Tok:   token.DEFINE,
+				X:     src,
+				Body:  &ast.BlockStmt{List: body},
+			},
+		)}, nil
+	case *PointerCopy:
+		// Pointers to values other than structs are set explicitly, even
+		// to the zero value, so they are replaced.
+		elem, ok := n.Elem.(*StructCopy)
+		if !ok || !mergesFields(elem) {
+			return m.replace(dst, src, member, sc, depth-1), nil
+		}
+
+		ifScope := sc.inner()
+		body, err := m.merge(dst, src, elem, ifScope, depth)
+		if err != nil {
+			return nil, err
+		}
+		if len(body) == 0 {
+			return m.replace(dst, src, member, sc, depth-1), nil
+		}
+
+		// A nil destination is merged into as a new zero value.
+		stmts := []ast.Stmt{ifNil(dst, assign(dst, call(ident("new"), g.typeExpr(elem.Type, m.x))))}
+		return []ast.Stmt{ifNotNil(src, append(stmts, body...)...)}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// replace returns the statements assigning a deep copy of src, the member at
+// depth, to dst, unless src is the zero value.
+func (m merger) replace(dst, src ast.Expr, member Member, sc *scope, depth int) []ast.Stmt {
+	ifScope := sc.inner()
+	stmts := m.copy(dst, src, m.copies.at(member, max(depth, 1)), ifScope, max(depth, 1))
+
+	// The deep copies of pointers, slices, maps and channels may check
+	// that src is not nil already.
+	cond := m.g.nonZero(src, member.Type)
+	if len(stmts) == 1 {
+		if s, ok := stmts[0].(*ast.IfStmt); ok && s.Init == nil && s.Else == nil && types.ExprString(s.Cond) == types.ExprString(cond) {
+			return stmts
+		}
+	}
+
+	return []ast.Stmt{&ast.IfStmt{Cond: cond, Body: &ast.BlockStmt{List: stmts}}}
+}
+
+// copy returns the statements assigning a deep copy of src to dst, following
+// the copy plan at depth. The depth is past the top level, so that deep copy
+// methods are reused.
+func (m merger) copy(dst, src ast.Expr, cp Plan, sc *scope, depth int) []ast.Stmt {
+	stmts := m.e.emit(src, dst, cp, sc, depth)
+	if len(stmts) > 0 && assignsWhole(cp) {
+		return stmts
+	}
+
+	return append([]ast.Stmt{assign(dst, src)}, stmts...)
+}
+
+// declareCopy returns the statements declaring the variable v, assigned a
+// deep copy of src following the copy plan at depth.
+func (m merger) declareCopy(v string, src ast.Expr, cp Plan, sc *scope, depth int) []ast.Stmt {
+	stmts := m.copy(ident(v), src, cp, sc, depth)
+	if s, ok := stmts[0].(*ast.AssignStmt); ok && s.Tok == token.ASSIGN && types.ExprString(s.Lhs[0]) == v {
+		return append([]ast.Stmt{define(v, s.Rhs[0])}, stmts[1:]...)
+	}
+
+	return append([]ast.Stmt{varDecl(v, m.g.typeExpr(cp.At().Type, m.x), nil)}, stmts...)
+}
+
+// appendCopy returns the statements appending a deep copy of the elements
+// of the slice src, the member at depth, to the slice dst.
+func (m merger) appendCopy(dst, src ast.Expr, member Member, sc *scope, depth int) []ast.Stmt {
+	appended := func(elems ast.Expr) ast.Stmt {
+		return assign(dst, &ast.CallExpr{Fun: ident("append"), Args: []ast.Expr{dst, elems}, Ellipsis: 1})
+	}
+
+	// append copies the elements, so only their members may need deep
+	// copies.
+	cp := m.copies.at(member, depth)
+	if s, ok := cp.(*SliceCopy); ok && !deep(s.Elem) {
+		return []ast.Stmt{appended(src)}
+	}
+
+	ifScope := sc.inner()
+	cpv := ifScope.declare(exprIdent(dst) + "_src")
+	stmts := m.declareCopy(cpv, src, cp, ifScope, depth)
+
+	return []ast.Stmt{&ast.IfStmt{
+		Cond: &ast.BinaryExpr{X: call(ident("len"), src), Op: token.GTR, Y: &ast.BasicLit{Kind: token.INT, Value: "0"}},
+		Body: &ast.BlockStmt{List: append(stmts, appended(ident(cpv)))},
+	}}
+}
+
+// assignsWhole reports whether the deep copy of the plan assigns the value
+// as a whole, rather than its members.
+func assignsWhole(cp Plan) bool {
+	switch cp.(type) {
+	case *PointerCopy, *SliceCopy, *MapCopy, *ChanCopy, *MethodReuse:
+		return true
+	default:
+		return false
+	}
+}
+
+// deep reports whether the plan copies anything into newly allocated
+// memory, or by a method.
+func deep(cp Plan) bool {
+	switch n := cp.(type) {
+	case *StructCopy:
+		return slices.ContainsFunc(n.Fields, func(f Field) bool { return deep(f.Copy) })
+	case *ArrayCopy:
+		return deep(n.Elem)
+	case *Skip, *ShallowCopy:
+		return false
+	default:
+		return true
+	}
+}
+
+// nonZero returns the condition of the value v of type m not being the zero
+// value.
+func (g Generator) nonZero(v ast.Expr, m types.Type) ast.Expr {
+	neq := func(y ast.Expr) ast.Expr {
+		return &ast.BinaryExpr{X: v, Op: token.NEQ, Y: y}
+	}
+
+	switch u := m.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return v
+		case u.Info()&types.IsString != 0:
+			return neq(&ast.BasicLit{Kind: token.STRING, Value: `""`})
+		case u.Kind() == types.UnsafePointer:
+			return neq(ident("nil"))
+		default:
+			return neq(&ast.BasicLit{Kind: token.INT, Value: "0"})
+		}
+	case *types.Struct, *types.Array:
+		if n, ok := m.(methoder); ok && hasIsZero(n) {
+			return &ast.UnaryExpr{Op: token.NOT, X: call(selector(v, "IsZero"))}
+		}
+		valueOf := call(selector(ident(g.importPath("reflect")), "ValueOf"), v)
+		return &ast.UnaryExpr{Op: token.NOT, X: call(selector(valueOf, "IsZero"))}
+	default:
+		return neq(ident("nil"))
+	}
+}
+
+// hasIsZero reports whether the type has an IsZero() bool method, as
+// time.Time does.
+func hasIsZero(v methoder) bool {
+	for i := 0; i < v.NumMethods(); i++ {
+		m := v.Method(i)
+		if m.Name() != "IsZero" {
+			continue
+		}
+
+		sig, _ := m.Type().(*types.Signature)
+		if sig == nil || sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+			return false
+		}
+
+		res, ok := sig.Results().At(0).Type().Underlying().(*types.Basic)
+		return ok && res.Kind() == types.Bool
+	}
+
+	return false
+}
+
+// mergesFields reports whether the struct of the plan is merged field by
+// field. The unexported fields of a struct of another package are out of
+// reach, so it is replaced as a whole instead.
+func mergesFields(n *StructCopy) bool {
+	return !slices.ContainsFunc(n.Fields, func(f Field) bool {
+		skip, ok := f.Copy.(*Skip)
+		return ok && skip.Reason == UnexportedField
+	})
+}
+
+// hasMerge reports whether the type has a merge method, generated or
+// existing.
+func (g Generator) hasMerge(v methoder, generating []object) bool {
+	if types.IsInterface(v) {
+		return false
+	}
+
+	for _, t := range generating {
+		if types.Identical(v, t) {
+			return true
+		}
+	}
+
+	// The method is looked up on the pointer type, as merge methods have
+	// pointer receivers.
+	mset := types.NewMethodSet(types.NewPointer(v))
+	if sel := mset.Lookup(nil, mergeMethodName); sel != nil {
+		if m, ok := sel.Obj().(*types.Func); ok && m.Exported() {
+			return IsMergeMethod(m, mergeMethodName)
+		}
+	}
+
+	return false
+}
+
+// IsMergeMethod reports whether m is a merge method with the given name: it
+// takes a single value of its receiver type, and returns nothing.
+func IsMergeMethod(m *types.Func, name string) bool {
+	if m.Name() != name {
+		return false
+	}
+
+	sig, _ := m.Type().(*types.Signature)
+	if sig == nil || sig.Recv() == nil || sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+		return false
+	}
+
+	recvType, _ := reducePointer(sig.Recv().Type())
+	return types.Identical(sig.Params().At(0).Type(), recvType)
+}
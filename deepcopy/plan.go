@@ -0,0 +1,288 @@
+package deepcopy
+
+import (
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Plan is a node of the copy plan of a type: how its deep copy method copies
+// a member of it. The nodes are *StructCopy, *ArrayCopy, *SliceCopy,
+// *MapCopy, *PointerCopy, *ChanCopy, *MethodReuse, *Skip and *ShallowCopy.
+// The Equal, Diff, DeepSize and MergeFrom methods are generated from plans
+// of the same members, reusing their own methods.
+type Plan interface {
+	// At returns the member the node copies.
+	At() Member
+}
+
+// Member is a member of a type, copied by a node of its copy plan.
+type Member struct {
+	// Selector is the path of the member, in the syntax of the skip
+	// selectors. It is empty for the type itself. The key and the value of a
+	// map are both selected by [k].
+	Selector string
+	// Type is the type of the member, as declared.
+	Type types.Type
+}
+
+// At returns the member itself, so that the nodes embedding it implement
+// Plan.
+func (m Member) At() Member {
+	return m
+}
+
+// StructCopy copies the fields of a struct, after assigning the whole value.
+type StructCopy struct {
+	Member
+	Fields []Field
+}
+
+// Field is a field of a struct, along with its copy plan.
+type Field struct {
+	Name string
+	Copy Plan
+}
+
+// ArrayCopy copies the elements of an array, after assigning the whole value.
+type ArrayCopy struct {
+	Member
+	Elem Plan
+}
+
+// SliceCopy allocates a new slice of the same length, copying the elements
+// into it.
+type SliceCopy struct {
+	Member
+	Elem Plan
+}
+
+// MapCopy allocates a new map of the same length, copying the keys and the
+// values into it.
+type MapCopy struct {
+	Member
+	Key, Elem Plan
+}
+
+// PointerCopy allocates a new value, copying the pointed to one into it.
+type PointerCopy struct {
+	Member
+	Elem Plan
+}
+
+// ChanCopy allocates a new channel of the same capacity. The buffered values
+// are not copied.
+type ChanCopy struct {
+	Member
+}
+
+// MethodReuse calls an existing or generated deep copy method of the member,
+// or of the value it points to when Pointer is set.
+type MethodReuse struct {
+	Member
+	Method string
+	// Pointer reports whether the member is a pointer to the type with the
+	// method, and Result whether the method returns a pointer, or takes one
+	// along with the receiver, as equality methods may. When they differ,
+	// the address of the result or argument, or the value it points to, is
+	// used.
+	Pointer, Result bool
+}
+
+// SkipReason is why a member is shared with the original.
+type SkipReason int
+
+const (
+	// SkippedBySelector members are matched by a skip selector.
+	SkippedBySelector SkipReason = iota
+	// PastMaxDepth members are deeper than the max depth.
+	PastMaxDepth
+	// UnexportedField members are unexported fields of a type of another
+	// package, which can't be accessed.
+	UnexportedField
+)
+
+// Skip leaves the member shared with the original, as assigned along with
+// the enclosing value.
+type Skip struct {
+	Member
+	Reason SkipReason
+	// By is the skip selector matching the member, if skipped by one. Within
+	// the keys and values of a map, skip selectors are relative to them.
+	By string
+}
+
+// ShallowCopy members are fully copied by the assignment of the enclosing
+// value, unless they are interfaces or functions.
+type ShallowCopy struct {
+	Member
+}
+
+// CopyPlans returns the copy plans of the deep copy methods of the types of
+// the package, as they are generated.
+func (g Generator) CopyPlans(types []string, p *packages.Package) ([]Plan, error) {
+	if g.markers {
+		g.isPtrRecv = true
+	}
+
+	objs, _, err := g.locateTypes(types, p)
+	if err != nil {
+		return nil, withPackageErrors(err, p)
+	}
+
+	plans := make([]Plan, len(objs))
+	for i, obj := range objs {
+		if err := checkValid(obj); err != nil {
+			return nil, withPackageErrors(err, p)
+		}
+
+		plans[i] = g.copyPlan(obj, p.Name, g.skipLists.Get(i), objs)
+	}
+
+	return plans, nil
+}
+
+// copyPlan returns the copy plan of the type of the package named x.
+func (g Generator) copyPlan(obj object, x string, skips skips, generating []object) Plan {
+	return g.copyPlanner(x, skips, generating).plan("", "", obj, 0)
+}
+
+// copyPlanner returns the planner of the deep copy methods.
+func (g Generator) copyPlanner(x string, skips skips, generating []object) planner {
+	return planner{g: g, x: x, skips: skips, find: func(v methoder) (string, bool, bool) {
+		hasMethod, isPointer := g.hasDeepCopy(v, generating)
+		return g.methodName, isPointer, hasMethod
+	}}
+}
+
+// methodFinder looks up the method a plan reuses for the values of a type,
+// returning its name, and whether it returns or takes a pointer.
+type methodFinder func(v methoder) (name string, isPointer, ok bool)
+
+// planner builds copy plans, which the methods are generated from. find
+// looks up the methods the plans reuse.
+type planner struct {
+	g     Generator
+	x     string
+	skips skips
+	find  methodFinder
+}
+
+// plan returns the copy plan of the member of type m selected by sel, at the
+// given depth. skipSel is the selector the skip selectors are matched
+// against, relative to the innermost map entry.
+func (pl planner) plan(sel, skipSel string, m types.Type, depth int) Plan {
+	g := pl.g
+	initial := depth == 0
+	member := Member{Selector: sel, Type: m}
+	m = types.Unalias(m)
+
+	if g.maxDepth > 0 && depth >= g.maxDepth {
+		return &Skip{Member: member, Reason: PastMaxDepth}
+	}
+
+	var needExported bool
+	if n, ok := m.(*types.Named); ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Name() != pl.x {
+		needExported = true
+	}
+
+	if v, ok := m.(methoder); ok && !initial {
+		if reuse, ok := pl.reuse(member, v, false); ok {
+			return reuse
+		}
+	}
+
+	depth++
+	switch v := m.Underlying().(type) {
+	case *types.Struct:
+		s := &StructCopy{Member: member}
+		for i := 0; i < v.NumFields(); i++ {
+			field := v.Field(i)
+			if field.Name() == "_" {
+				continue
+			}
+
+			fsel := strings.TrimPrefix(sel+"."+field.Name(), ".")
+			fskip := strings.TrimPrefix(skipSel+"."+field.Name(), ".")
+
+			var fp Plan
+			switch {
+			case needExported && !field.Exported():
+				fp = &Skip{Member: Member{Selector: fsel, Type: field.Type()}, Reason: UnexportedField}
+			case pl.skips.Contains(fskip):
+				fp = &Skip{Member: Member{Selector: fsel, Type: field.Type()}, By: fskip}
+			default:
+				fp = pl.plan(fsel, fskip, field.Type(), depth)
+			}
+			s.Fields = append(s.Fields, Field{Name: field.Name(), Copy: fp})
+		}
+		return s
+	case *types.Array:
+		return &ArrayCopy{Member: member, Elem: pl.elem(sel, skipSel, v.Elem(), depth)}
+	case *types.Slice:
+		return &SliceCopy{Member: member, Elem: pl.elem(sel, skipSel, v.Elem(), depth)}
+	case *types.Map:
+		esel, eskip := sel+"[k]", skipSel+"[k]"
+		if pl.skips.Contains(eskip) {
+			return &MapCopy{
+				Member: member,
+				Key:    &Skip{Member: Member{Selector: esel, Type: v.Key()}, By: eskip},
+				Elem:   &Skip{Member: Member{Selector: esel, Type: v.Elem()}, By: eskip},
+			}
+		}
+		return &MapCopy{
+			Member: member,
+			Key:    pl.plan(esel, "", v.Key(), depth),
+			Elem:   pl.plan(esel, "", v.Elem(), depth),
+		}
+	case *types.Pointer:
+		if e, ok := types.Unalias(v.Elem()).(methoder); ok && !initial {
+			if reuse, ok := pl.reuse(member, e, true); ok {
+				return reuse
+			}
+		}
+
+		// The pointed to value is copied in place of the pointer.
+		return &PointerCopy{Member: member, Elem: pl.plan(sel, skipSel, v.Elem(), depth)}
+	case *types.Chan:
+		return &ChanCopy{Member: member}
+	default:
+		return &ShallowCopy{Member: member}
+	}
+}
+
+// at returns the plan of the member at the given depth, as planned within
+// the plan of the type.
+func (pl planner) at(member Member, depth int) Plan {
+	// Within the keys and values of a map, skip selectors are relative to
+	// them.
+	skipSel := member.Selector
+	if i := strings.LastIndex(skipSel, "[k]"); i >= 0 {
+		skipSel = strings.TrimPrefix(skipSel[i+len("[k]"):], ".")
+	}
+
+	return pl.plan(member.Selector, skipSel, member.Type, depth)
+}
+
+// elem returns the plan of the elements of type m of the array or slice
+// selected by sel.
+func (pl planner) elem(sel, skipSel string, m types.Type, depth int) Plan {
+	esel, eskip := sel+"[i]", skipSel+"[i]"
+	if pl.skips.Contains(eskip) {
+		return &Skip{Member: Member{Selector: esel, Type: m}, By: eskip}
+	}
+
+	return pl.plan(esel, eskip, m, depth)
+}
+
+// reuse returns the reuse of the method the planner finds for the type, if
+// it has one, for a value of it, or a pointer to it if pointer is set.
+func (pl planner) reuse(member Member, v methoder, pointer bool) (*MethodReuse, bool) {
+	name, isPointer, ok := pl.find(v)
+	if !ok {
+		return nil, false
+	}
+
+	return &MethodReuse{Member: member, Method: name, Pointer: pointer, Result: isPointer}, true
+}
@@ -0,0 +1,65 @@
+package deepcopy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyPlans(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata")
+	require.NoError(t, err)
+
+	g := NewGenerator(WithSkipLists(SkipLists{{"Slice": struct{}{}}}))
+	plans, err := g.CopyPlans([]string{"Foo"}, pkgs[0])
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+
+	foo, ok := plans[0].(*StructCopy)
+	require.True(t, ok, "plan of Foo = %T", plans[0])
+	assert.Equal(t, "", foo.Selector)
+	require.Len(t, foo.Fields, 3)
+
+	m, ok := foo.Fields[0].Copy.(*MapCopy)
+	require.True(t, ok, "plan of Map = %T", foo.Fields[0].Copy)
+	assert.Equal(t, "Map", m.Selector)
+	assert.IsType(t, &ShallowCopy{}, m.Key)
+
+	// The skip selectors are relative to the map values.
+	bar, ok := m.Elem.(*PointerCopy).Elem.(*StructCopy)
+	require.True(t, ok, "plan of Map[k] = %T", m.Elem)
+	assert.Equal(t, &Skip{Member: Member{Selector: "Map[k].Slice", Type: bar.Fields[1].Copy.At().Type}, By: "Slice"}, bar.Fields[1].Copy)
+
+	assert.IsType(t, &ChanCopy{}, foo.Fields[1].Copy)
+
+	baz, ok := foo.Fields[2].Copy.(*StructCopy)
+	require.True(t, ok, "plan of baz = %T", foo.Fields[2].Copy)
+	assert.IsType(t, &PointerCopy{}, baz.Fields[1].Copy)
+	assert.Equal(t, "baz.StringPointer", baz.Fields[1].Copy.At().Selector)
+}
+
+func TestCopyPlansReuse(t *testing.T) {
+	pkgs, err := Load(LoadConfig{}, "../testdata/explain")
+	require.NoError(t, err)
+
+	g := NewGenerator(WithMaxDepth(3))
+	plans, err := g.CopyPlans([]string{"Plan"}, pkgs[0])
+	require.NoError(t, err)
+
+	fields := map[string]Plan{}
+	for _, f := range plans[0].(*StructCopy).Fields {
+		fields[f.Name] = f.Copy
+	}
+
+	assert.Equal(t, &MethodReuse{Member: fields["Next"].At(), Method: "DeepCopy", Pointer: true, Result: false}, fields["Next"])
+	assert.Equal(t, &MethodReuse{Member: Member{Selector: "Steps[i]", Type: fields["Steps"].(*SliceCopy).Elem.At().Type}, Method: "DeepCopy"}, fields["Steps"].(*SliceCopy).Elem)
+	assert.IsType(t, &ShallowCopy{}, fields["Hook"])
+	assert.Equal(t, &MethodReuse{Member: Member{Selector: "Slots[i]", Type: fields["Slots"].(*ArrayCopy).Elem.At().Type}, Method: "DeepCopy", Pointer: true}, fields["Slots"].(*ArrayCopy).Elem)
+
+	next := fields["Level"].(*StructCopy).Fields[0].Copy.(*PointerCopy)
+	assert.Equal(t, PastMaxDepth, next.Elem.(*Skip).Reason)
+
+	loc := fields["At"].(*StructCopy).Fields[2].Copy
+	assert.Equal(t, UnexportedField, loc.(*Skip).Reason)
+}
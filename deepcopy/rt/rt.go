@@ -0,0 +1,378 @@
+// Package rt deep copies values by reflection at run time. It complements the
+// generated methods for values they can't reach, such as the ones coming from
+// plugins or held in interfaces, and follows the same rules: existing deep copy
+// methods are reused, and skip selectors leave members shallow copied. It also
+// estimates the memory values hold, the way the generated DeepSize methods
+// do.
+package rt
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+type config struct {
+	methodName string
+	skips      map[string]struct{}
+}
+
+// Option is a function to specify option for Copy and NoAlias.
+type Option func(*config)
+
+// WithMethodName is an option to specify the name of the deep copy method to
+// reuse. An empty name disables method reuse. Defaults to "DeepCopy".
+func WithMethodName(n string) Option {
+	return func(c *config) {
+		c.methodName = n
+	}
+}
+
+// WithSkip is an option to specify field/slice/map selectors to shallow copy,
+// in the syntax of the --skip flag of deep-copy: "B", "B.I", "[i]", "Map[k]".
+func WithSkip(selectors ...string) Option {
+	return func(c *config) {
+		if c.skips == nil {
+			c.skips = map[string]struct{}{}
+		}
+		for _, sel := range selectors {
+			c.skips[sel] = struct{}{}
+		}
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{methodName: "DeepCopy"}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// Copy returns a deep copy of v. Nil slices and maps stay nil, empty ones stay
+// empty, and pointers shared within v, including cycles, are shared within the
+// copy as well. The deep copy method of v itself is never called, so that it
+// may be implemented with Copy, but the one of the dynamic value of v is when T
+// is an interface type, as in Copy[any].
+func Copy[T any](v T, opts ...Option) T {
+	c := copier{config: newConfig(opts), seen: map[seenKey]reflect.Value{}}
+
+	var cp T
+	reflect.ValueOf(&cp).Elem().Set(c.copy(reflect.ValueOf(&v).Elem(), "", true))
+	return cp
+}
+
+type seenKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+type copier struct {
+	config
+	seen map[seenKey]reflect.Value
+}
+
+// copy returns a deep copy of v, which sel selects. root is set while v is
+// the copied value itself, or what it points to, of the type Copy was called
+// with.
+func (c *copier) copy(v reflect.Value, sel string, root bool) reflect.Value {
+	if _, ok := c.skips[sel]; ok {
+		return v
+	}
+
+	t := v.Type()
+	if !root && v.Kind() != reflect.Interface {
+		if cp, ok := c.reuse(v); ok {
+			return cp
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		v = addressable(v)
+		cp := reflect.New(t).Elem()
+		cp.Set(v)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			fsel := f.Name
+			if sel != "" {
+				fsel = sel + "." + f.Name
+			}
+			field(cp, i).Set(c.copy(field(v, i), fsel, false))
+		}
+		return cp
+	case reflect.Array:
+		v = addressable(v)
+		cp := reflect.New(t).Elem()
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(c.copy(v.Index(i), sel+"[i]", false))
+		}
+		return cp
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+
+		key := seenKey{ptr: v.Pointer(), typ: t}
+		if cp, ok := c.seen[key]; ok {
+			return cp
+		}
+
+		cp := reflect.New(t.Elem())
+		c.seen[key] = cp
+		cp.Elem().Set(c.copy(v.Elem(), sel, root))
+		return cp
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		key := seenKey{ptr: v.Pointer(), len: v.Len(), typ: t}
+		if cp, ok := c.seen[key]; ok {
+			return cp
+		}
+
+		cp := reflect.MakeSlice(t, v.Len(), v.Len())
+		c.seen[key] = cp
+		for i := 0; i < v.Len(); i++ {
+			cp.Index(i).Set(c.copy(v.Index(i), sel+"[i]", false))
+		}
+		return cp
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		key := seenKey{ptr: v.Pointer(), typ: t}
+		if cp, ok := c.seen[key]; ok {
+			return cp
+		}
+
+		cp := reflect.MakeMapWithSize(t, v.Len())
+		c.seen[key] = cp
+		for iter := v.MapRange(); iter.Next(); {
+			cp.SetMapIndex(c.copy(iter.Key(), sel+"[k]", false), c.copy(iter.Value(), sel+"[k]", false))
+		}
+		return cp
+	case reflect.Chan:
+		if v.IsNil() {
+			return v
+		}
+
+		return reflect.MakeChan(t, v.Cap())
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		// The dynamic value is of another type than the one Copy was
+		// called with, so its method is reused even at the root.
+		cp := reflect.New(t).Elem()
+		cp.Set(c.copy(v.Elem(), sel, false))
+		return cp
+	default:
+		return v
+	}
+}
+
+// reuse calls the deep copy method of v, if it has one whose result is of
+// the type of v, or a pointer to it.
+func (c *copier) reuse(v reflect.Value) (reflect.Value, bool) {
+	if c.methodName == "" {
+		return reflect.Value{}, false
+	}
+
+	t := v.Type()
+	recv := v
+	if t.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+	} else {
+		t = reflect.PointerTo(t)
+	}
+
+	method, ok := t.MethodByName(c.methodName)
+	if !ok || method.Type.NumIn() != 1 || method.Type.NumOut() != 1 {
+		return reflect.Value{}, false
+	}
+
+	if recv.Kind() != reflect.Pointer {
+		recv = addressable(v).Addr()
+	}
+	m := recv.Method(method.Index)
+	t = v.Type()
+
+	ret := m.Type().Out(0)
+	switch {
+	case ret == t:
+		return m.Call(nil)[0], true
+	case ret.Kind() == reflect.Pointer && ret.Elem() == t:
+		cp := m.Call(nil)[0]
+		if cp.IsNil() {
+			return reflect.Zero(t), true
+		}
+		return cp.Elem(), true
+	case t.Kind() == reflect.Pointer && ret == t.Elem():
+		cp := reflect.New(ret)
+		cp.Elem().Set(m.Call(nil)[0])
+		return cp, true
+	}
+
+	return reflect.Value{}, false
+}
+
+// addressable returns v, or an addressable copy of it.
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+
+	cp := reflect.New(v.Type()).Elem()
+	cp.Set(v)
+	return cp
+}
+
+// field returns the i-th field of the addressable struct v, settable even if
+// it's unexported.
+func field(v reflect.Value, i int) reflect.Value {
+	f := v.Field(i)
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem()
+}
+
+// NoAlias returns an error naming the first pair of selectors through which a
+// and b share mutable memory: pointed to values, slice elements, maps and
+// channels. Strings are immutable and never reported. Selectors passed with
+// WithSkip are left out on both sides.
+func NoAlias(a, b any, opts ...Option) error {
+	c := newConfig(opts)
+
+	var owned []span
+	ra := &regions{config: c, seen: map[seenKey]bool{}, visit: func(s span) error {
+		owned = append(owned, s)
+		return nil
+	}}
+	if err := ra.walk(reflect.ValueOf(&a).Elem(), ""); err != nil {
+		return err
+	}
+
+	rb := &regions{config: c, seen: map[seenKey]bool{}, visit: func(s span) error {
+		for _, o := range owned {
+			if s.start < o.end && o.start < s.end {
+				return fmt.Errorf("%s shares memory with %s", s.selector(), o.selector())
+			}
+		}
+		return nil
+	}}
+	return rb.walk(reflect.ValueOf(&b).Elem(), "")
+}
+
+// span is a range of mutable memory reachable through a selector.
+type span struct {
+	start, end uintptr
+	sel        string
+}
+
+func (s span) selector() string {
+	if s.sel == "" {
+		return "value"
+	}
+	return s.sel
+}
+
+type regions struct {
+	config
+	seen  map[seenKey]bool
+	visit func(span) error
+}
+
+func (r *regions) walk(v reflect.Value, sel string) error {
+	if _, ok := r.skips[sel]; ok {
+		return nil
+	}
+
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Struct:
+		v = addressable(v)
+		for i := 0; i < t.NumField(); i++ {
+			fsel := t.Field(i).Name
+			if sel != "" {
+				fsel = sel + "." + fsel
+			}
+			if err := r.walk(field(v, i), fsel); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		v = addressable(v)
+		for i := 0; i < v.Len(); i++ {
+			if err := r.walk(v.Index(i), sel+"[i]"); err != nil {
+				return err
+			}
+		}
+	case reflect.Pointer:
+		if v.IsNil() || !r.enter(v.Pointer(), 0, t) {
+			return nil
+		}
+		if err := r.add(v.Pointer(), t.Elem().Size(), sel); err != nil {
+			return err
+		}
+		return r.walk(v.Elem(), sel)
+	case reflect.Slice:
+		if v.IsNil() || !r.enter(v.Pointer(), v.Len(), t) {
+			return nil
+		}
+		if err := r.add(v.Pointer(), uintptr(v.Len())*t.Elem().Size(), sel); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := r.walk(v.Index(i), sel+"[i]"); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() || !r.enter(v.Pointer(), 0, t) {
+			return nil
+		}
+		if err := r.add(v.Pointer(), 1, sel); err != nil {
+			return err
+		}
+		for iter := v.MapRange(); iter.Next(); {
+			if err := r.walk(iter.Key(), sel+"[k]"); err != nil {
+				return err
+			}
+			if err := r.walk(iter.Value(), sel+"[k]"); err != nil {
+				return err
+			}
+		}
+	case reflect.Chan:
+		if !v.IsNil() {
+			return r.add(v.Pointer(), 1, sel)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			return r.walk(v.Elem(), sel)
+		}
+	}
+
+	return nil
+}
+
+// enter reports whether the memory at ptr hasn't been walked yet.
+func (r *regions) enter(ptr uintptr, n int, t reflect.Type) bool {
+	key := seenKey{ptr: ptr, len: n, typ: t}
+	if r.seen[key] {
+		return false
+	}
+	r.seen[key] = true
+	return true
+}
+
+func (r *regions) add(ptr, size uintptr, sel string) error {
+	if size == 0 {
+		return nil
+	}
+	return r.visit(span{start: ptr, end: ptr + size, sel: sel})
+}
@@ -0,0 +1,217 @@
+package rt_test
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/globusdigital/deep-copy/deepcopy/rt"
+	"github.com/globusdigital/deep-copy/internal/fixtures/order"
+	"github.com/stretchr/testify/assert"
+)
+
+type node struct {
+	Value int
+	Next  *node
+}
+
+type shared struct {
+	A, B *int
+}
+
+type withMethod struct {
+	Data []int
+}
+
+func (w withMethod) DeepCopy() withMethod {
+	return withMethod{Data: []int{-1}}
+}
+
+type holder struct {
+	W     withMethod
+	P     *withMethod
+	Items []string
+	Tags  map[string]*int
+	ch    chan int
+	Any   any
+}
+
+func TestCopy(t *testing.T) {
+	t.Run("nil and empty", func(t *testing.T) {
+		cp := rt.Copy(holder{Items: []string{}})
+		assert.NotNil(t, cp.Items)
+		assert.Empty(t, cp.Items)
+		assert.Nil(t, cp.Tags)
+		assert.Nil(t, cp.ch)
+
+		cp = rt.Copy(holder{Tags: map[string]*int{}})
+		assert.Nil(t, cp.Items)
+		assert.NotNil(t, cp.Tags)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		n := &node{Value: 1}
+		n.Next = &node{Value: 2, Next: n}
+
+		cp := rt.Copy(n)
+		assert.NotSame(t, n, cp)
+		assert.Same(t, cp, cp.Next.Next)
+		assert.Equal(t, 2, cp.Next.Value)
+	})
+
+	t.Run("shared pointers stay shared", func(t *testing.T) {
+		i := 1
+		cp := rt.Copy(shared{A: &i, B: &i})
+		assert.NotSame(t, &i, cp.A)
+		assert.Same(t, cp.A, cp.B)
+	})
+
+	t.Run("reuses methods", func(t *testing.T) {
+		cp := rt.Copy(holder{W: withMethod{Data: []int{1}}, P: &withMethod{Data: []int{1}}})
+		assert.Equal(t, []int{-1}, cp.W.Data)
+		assert.Equal(t, []int{-1}, cp.P.Data)
+
+		cp = rt.Copy(holder{W: withMethod{Data: []int{1}}}, rt.WithMethodName(""))
+		assert.Equal(t, []int{1}, cp.W.Data)
+	})
+
+	t.Run("never calls the method of the root", func(t *testing.T) {
+		cp := rt.Copy(withMethod{Data: []int{1}})
+		assert.Equal(t, []int{1}, cp.Data)
+	})
+
+	t.Run("calls the method of a value held in an interface", func(t *testing.T) {
+		var v any = withMethod{Data: []int{1}}
+		cp := rt.Copy(v)
+		assert.Equal(t, withMethod{Data: []int{-1}}, cp)
+
+		v = &withMethod{Data: []int{1}}
+		cp = rt.Copy(v)
+		assert.NotSame(t, v, cp)
+		assert.Equal(t, &withMethod{Data: []int{-1}}, cp)
+	})
+
+	t.Run("interfaces", func(t *testing.T) {
+		i := 1
+		h := holder{Any: &i}
+		cp := rt.Copy(h)
+		assert.NotSame(t, h.Any, cp.Any)
+		assert.Equal(t, h.Any, cp.Any)
+	})
+
+	t.Run("skips", func(t *testing.T) {
+		i := 1
+		h := holder{Tags: map[string]*int{"a": &i}, Items: []string{"a"}}
+
+		cp := rt.Copy(h, rt.WithSkip("Tags[k]"))
+		assert.Same(t, h.Tags["a"], cp.Tags["a"])
+		assert.NoError(t, rt.NoAlias(h, cp, rt.WithSkip("Tags[k]")))
+		assert.EqualError(t, rt.NoAlias(h, cp), "Tags[k] shares memory with Tags[k]")
+
+		cp = rt.Copy(h, rt.WithSkip("Items"))
+		assert.EqualError(t, rt.NoAlias(h, cp), "Items shares memory with Items")
+	})
+}
+
+func TestNoAlias(t *testing.T) {
+	i := 1
+	s := []int{1, 2, 3}
+
+	tests := []struct {
+		name    string
+		a, b    any
+		wantErr string
+	}{
+		{name: "disjoint", a: shared{A: &i}, b: shared{A: new(int)}},
+		{name: "shared pointer", a: shared{A: &i}, b: shared{B: &i}, wantErr: "B shares memory with A"},
+		{name: "overlapping slices", a: holder{Any: s}, b: holder{Any: s[2:]}, wantErr: "Any shares memory with Any"},
+		{name: "pointer into slice", a: s, b: &s[1], wantErr: "value shares memory with value"},
+		{name: "strings are immutable", a: holder{Items: []string{"a"}}, b: holder{Items: []string{"a"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rt.NoAlias(tt.a, tt.b)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeneratedMatchesReflective(t *testing.T) {
+	total := 9.5
+	zip := "12345"
+	note := "fragile"
+	o := order.Order{
+		ID:       1,
+		Customer: &order.Customer{Name: "c", Addresses: []*order.Address{{Street: "s", Zip: &zip}}},
+		Lines: []order.Line{
+			{SKU: "a", Quantity: 2, Options: map[string]*order.Option{"gift": {Name: "wrap", Price: &total}}},
+		},
+		Tags:  map[string][]string{"k": {"v"}, "empty": {}, "nil": nil},
+		Notes: []*string{&note},
+	}
+
+	generated := o.DeepCopy()
+	reflective := rt.Copy(o, rt.WithMethodName(""), rt.WithSkip("Notes"))
+
+	assert.True(t, reflect.DeepEqual(generated, reflective))
+	assert.NoError(t, rt.NoAlias(o, generated, rt.WithSkip("Notes")))
+	assert.NoError(t, rt.NoAlias(o, reflective, rt.WithSkip("Notes")))
+	assert.NoError(t, rt.NoAlias(generated, reflective, rt.WithSkip("Notes")))
+}
+
+func TestSize(t *testing.T) {
+	n := 1
+	// word is the size of pointers and ints, and of the words of string,
+	// slice and interface headers.
+	word := int(unsafe.Sizeof(n))
+	tests := []struct {
+		name string
+		size int
+		want int
+	}{
+		{name: "int", size: rt.Size(1), want: word},
+		{name: "string", size: rt.Size("abc"), want: 2*word + 3},
+		{name: "pointer", size: rt.Size(&n), want: 2 * word},
+		{name: "nil pointer", size: rt.Size((*int)(nil)), want: word},
+		{name: "slice capacity", size: rt.Size(make([]int32, 1, 4)), want: 3*word + 4*4},
+		{name: "map", size: rt.Size(map[string]int{"ab": 1}), want: word + 48 + (3*word + 1) + 2},
+		{name: "channel", size: rt.Size(make(chan int64, 2)), want: word + 96 + 2*8},
+		{name: "interface", size: rt.Size[any]("abc"), want: 4*word + 3},
+		{name: "shared pointer", size: rt.Size(shared{A: &n, B: &n}), want: 3 * word},
+		{name: "cycle", size: rt.Size(func() *node { v := &node{}; v.Next = v; return v }()), want: 3 * word},
+		{name: "skipped", size: rt.Size(holder{Items: []string{"a"}}, rt.WithSkip("Items")), want: rt.Size(holder{})},
+		{name: "skipped elements", size: rt.Size(holder{Items: []string{"ab"}}, rt.WithSkip("Items[i]")), want: rt.Size(holder{}) + 2*word},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.size)
+		})
+	}
+}
+
+func TestGeneratedSizeMatchesReflective(t *testing.T) {
+	total := 9.5
+	zip := "12345"
+	note := "fragile"
+	o := order.Order{
+		ID:       1,
+		Customer: &order.Customer{Name: "c", Addresses: make([]*order.Address, 1, 3)},
+		Lines: []order.Line{
+			{SKU: "a", Quantity: 2, Options: map[string]*order.Option{"gift": {Name: "wrap", Price: &total}, "none": nil}},
+		},
+		Tags:   map[string][]string{"k": {"v"}, "empty": {}, "nil": nil},
+		Notes:  []*string{&note},
+		Events: make(chan string, 4),
+	}
+	o.Customer.Addresses[0] = &order.Address{Street: "s", Zip: &zip}
+
+	assert.Equal(t, rt.Size(o, rt.WithSkip("Notes")), o.DeepSize())
+	assert.Equal(t, rt.Size(*o.Customer), o.Customer.DeepSize())
+	assert.Equal(t, rt.Size(order.Order{}), order.Order{}.DeepSize())
+	assert.Less(t, rt.Size(order.Order{}, rt.WithSkip("Notes")), rt.Size(o, rt.WithSkip("Notes")))
+}
@@ -0,0 +1,107 @@
+package rt
+
+import "reflect"
+
+// MapHeaderSize and ChanHeaderSize estimate the size of the runtime headers
+// of maps and channels. The generated DeepSize methods estimate them the same
+// way.
+const (
+	MapHeaderSize  = 48
+	ChanHeaderSize = 96
+)
+
+// mapSize returns an estimate of the memory a map of n entries holds itself:
+// its header, and a slot and a control byte per entry.
+func mapSize(n int, key, elem uintptr) int {
+	return MapHeaderSize + n*(int(key+elem)+1)
+}
+
+// chanSize returns an estimate of the memory the channel v holds: its header
+// and buffer.
+func chanSize(v reflect.Value) int {
+	return ChanHeaderSize + v.Cap()*int(v.Type().Elem().Size())
+}
+
+// Size returns an estimate of the number of bytes v holds: its own size, and
+// the size of the memory it references, which a deep copy allocates: pointed
+// to values, backing arrays by capacity, string bytes, map entries and channel
+// buffers. Values held in interfaces are counted, memory shared within v is
+// counted once, and selectors passed with WithSkip are left out.
+func Size[T any](v T, opts ...Option) int {
+	s := sizer{config: newConfig(opts), seen: map[seenKey]bool{}}
+
+	rv := reflect.ValueOf(&v).Elem()
+	return int(rv.Type().Size()) + s.indirect(rv, "")
+}
+
+type sizer struct {
+	config
+	seen map[seenKey]bool
+}
+
+// indirect returns the size of the memory v, which sel selects, references.
+func (s *sizer) indirect(v reflect.Value, sel string) int {
+	if _, ok := s.skips[sel]; ok {
+		return 0
+	}
+
+	t := v.Type()
+	var size int
+	switch v.Kind() {
+	case reflect.String:
+		size = v.Len()
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			fsel := t.Field(i).Name
+			if sel != "" {
+				fsel = sel + "." + fsel
+			}
+			size += s.indirect(v.Field(i), fsel)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			size += s.indirect(v.Index(i), sel+"[i]")
+		}
+	case reflect.Pointer:
+		if v.IsNil() || !s.enter(v.Pointer(), 0, t) {
+			return 0
+		}
+		size = int(t.Elem().Size()) + s.indirect(v.Elem(), sel)
+	case reflect.Slice:
+		if v.IsNil() || !s.enter(v.Pointer(), v.Len(), t) {
+			return 0
+		}
+		size = v.Cap() * int(t.Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += s.indirect(v.Index(i), sel+"[i]")
+		}
+	case reflect.Map:
+		if v.IsNil() || !s.enter(v.Pointer(), 0, t) {
+			return 0
+		}
+		size = mapSize(v.Len(), t.Key().Size(), t.Elem().Size())
+		for iter := v.MapRange(); iter.Next(); {
+			size += s.indirect(iter.Key(), sel+"[k]") + s.indirect(iter.Value(), sel+"[k]")
+		}
+	case reflect.Chan:
+		if !v.IsNil() {
+			size = chanSize(v)
+		}
+	case reflect.Interface:
+		if !v.IsNil() {
+			size = int(v.Elem().Type().Size()) + s.indirect(v.Elem(), sel)
+		}
+	}
+
+	return size
+}
+
+// enter reports whether the memory at ptr hasn't been counted yet.
+func (s *sizer) enter(ptr uintptr, n int, t reflect.Type) bool {
+	key := seenKey{ptr: ptr, len: n, typ: t}
+	if s.seen[key] {
+		return false
+	}
+	s.seen[key] = true
+	return true
+}
@@ -0,0 +1,69 @@
+package deepcopy
+
+import (
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// selectedTypes returns the named struct types of the package selected by
+// the all and typePattern options, in alphabetical order. Only exported types
+// are selected, leaving out excluded types and types with a hand-written deep
+// copy method.
+func (g Generator) selectedTypes(p *packages.Package) []string {
+	var selected []string
+	scope := p.Types.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() {
+			continue
+		}
+
+		named, ok := obj.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			continue
+		}
+
+		if !obj.Exported() || g.isExcluded(name) {
+			continue
+		}
+		if g.typePattern != nil && !g.typePattern.MatchString(name) {
+			continue
+		}
+		if g.hasHandWrittenMethod(p, name) {
+			continue
+		}
+
+		selected = append(selected, name)
+	}
+
+	return selected
+}
+
+// withSelectedTypes appends the types selected by the all and typePattern
+// options to the explicitly requested ones.
+func (g Generator) withSelectedTypes(kinds []string, p *packages.Package) []string {
+	requested := make(map[string]struct{}, len(kinds))
+	for _, kind := range kinds {
+		requested[kind] = struct{}{}
+	}
+
+	kinds = append([]string(nil), kinds...)
+	for _, name := range g.selectedTypes(p) {
+		if _, ok := requested[name]; !ok {
+			kinds = append(kinds, name)
+		}
+	}
+
+	return kinds
+}
+
+// isExcluded reports whether the type is left out of the types selected by
+// the all, typePattern and markers options.
+func (g Generator) isExcluded(name string) bool {
+	return slices.Contains(g.excludedTypes, name)
+}
@@ -0,0 +1,47 @@
+package deepcopy
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestSelectedTypes(t *testing.T) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+	}, "../testdata/selection")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		opts []GeneratorOption
+		want []string
+	}{
+		{name: "all", opts: []GeneratorOption{WithAllTypes(true)}, want: []string{"CreateReq", "CreateResp", "Server"}},
+		{name: "pattern matching an unexported type", opts: []GeneratorOption{WithTypePattern(regexp.MustCompile("Req$"))}, want: []string{"CreateReq"}},
+		{name: "excluded type", opts: []GeneratorOption{WithAllTypes(true), WithExcludedTypes([]string{"Server"})}, want: []string{"CreateReq", "CreateResp"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewGenerator(tt.opts...).selectedTypes(pkgs[0]))
+		})
+	}
+}
+
+func TestMarkedTypesExcluded(t *testing.T) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+	}, "../testdata/k8s/apis/v1")
+	require.NoError(t, err)
+
+	g := NewGenerator(WithMarkers(true), WithExcludedTypes([]string{"Selector"}))
+	_, selected := g.markedTypes(pkgs[0])
+	assert.Equal(t, []string{"Widget", "WidgetSpec"}, selected)
+
+	kinds, _ := g.withMarkedTypes([]string{"Selector"}, pkgs[0])
+	assert.Equal(t, []string{"Selector", "Widget", "WidgetSpec"}, kinds, "requested types are kept")
+}
@@ -0,0 +1,247 @@
+package deepcopy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+
+	"github.com/globusdigital/deep-copy/deepcopy/rt"
+	"golang.org/x/tools/go/packages"
+)
+
+// sizeMethodName is the name of the generated size methods, and of the
+// existing ones they call.
+const sizeMethodName = "DeepSize"
+
+// generateSizeFunc builds the DeepSize method of the type, estimating the
+// size of the value and of the memory its deep copy method allocates, and
+// leaving out the skipped members.
+func (g Generator) generateSizeFunc(p *packages.Package, obj object, skips skips, generating []object) *ast.FuncDecl {
+	kind := obj.Obj().Name()
+	var recv ast.Expr = ident(kind)
+	var ptr string
+	if g.isPtrRecv {
+		ptr = "*"
+		recv = &ast.StarExpr{X: recv}
+	}
+
+	sc := g.funcScope()
+	source, size := ident(sc.declare("o")), ident(sc.declare("size"))
+	s := sizer{g: g, x: p.Name, size: size}
+
+	var body []ast.Stmt
+	var v ast.Expr = source
+	if g.isPtrRecv {
+		body = append(body, ifNil(source, &ast.ReturnStmt{Results: []ast.Expr{&ast.BasicLit{Kind: token.INT, Value: "0"}}}))
+		v, _ = derefRecv(source, source, obj)
+		body = append(body, define(size.Name, s.sizeof(&ast.StarExpr{X: source})))
+	} else {
+		body = append(body, define(size.Name, s.sizeof(source)))
+	}
+
+	body = append(body, s.measure(v, g.sizePlanner(p.Name, skips, generating).plan("", "", obj, 0), sc, 0)...)
+	body = append(body, &ast.ReturnStmt{Results: []ast.Expr{size}})
+
+	return &ast.FuncDecl{
+		Doc: &ast.CommentGroup{List: []*ast.Comment{
+			{Text: fmt.Sprintf("// %s estimates the bytes %s%s and the memory its deep copy allocates hold", sizeMethodName, ptr, kind)},
+		}},
+		Recv: &ast.FieldList{List: []*ast.Field{{Names: []*ast.Ident{source}, Type: recv}}},
+		Name: ident(sizeMethodName),
+		Type: &ast.FuncType{
+			Params:  &ast.FieldList{},
+			Results: &ast.FieldList{List: []*ast.Field{{Type: ident("int")}}},
+		},
+		Body: &ast.BlockStmt{List: body},
+	}
+}
+
+// sizePlanner returns the planner of the size methods, which reuse the size
+// methods of the members.
+func (g Generator) sizePlanner(x string, skips skips, generating []object) planner {
+	return planner{g: g, x: x, skips: skips, find: func(v methoder) (string, bool, bool) {
+		hasMethod, isPointer := g.hasSize(v, generating)
+		return sizeMethodName, isPointer, hasMethod
+	}}
+}
+
+// sizer builds the statements of size methods from their plans, adding to
+// the size variable. x is the name of the package of the method.
+type sizer struct {
+	g    Generator
+	x    string
+	size *ast.Ident
+}
+
+// measure returns the statements adding the size of the memory the value v
+// references, which its deep copy allocates, following the plan. The locals
+// are declared in sc. depth follows the one of the plan.
+func (s sizer) measure(v ast.Expr, plan Plan, sc *scope, depth int) []ast.Stmt {
+	switch n := plan.(type) {
+	case *ShallowCopy:
+		// Deep copies share the bytes of strings, which are counted all the
+		// same, as they are held by the copy. Interface values and functions
+		// are shared by deep copies, so only their own size, part of the
+		// enclosing value, is counted.
+		if t, ok := n.Type.Underlying().(*types.Basic); ok && t.Info()&types.IsString != 0 {
+			return []ast.Stmt{s.add(call(ident("len"), v))}
+		}
+		return nil
+	case *ChanCopy:
+		// The buffer of the new channel, by its capacity, along with its
+		// header.
+		elem := n.Type.Underlying().(*types.Chan).Elem()
+		return []ast.Stmt{ifNotNil(v, s.add(&ast.BinaryExpr{
+			X:  &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(rt.ChanHeaderSize)},
+			Op: token.ADD,
+			Y:  &ast.BinaryExpr{X: call(ident("cap"), v), Op: token.MUL, Y: s.sizeof(s.zero(elem))},
+		}))}
+	case *MethodReuse:
+		if n.Pointer {
+			// The method counts the pointed to value.
+			return []ast.Stmt{ifNotNil(v, s.add(call(selector(v, n.Method))))}
+		}
+
+		// The size of the value itself is part of the enclosing value, so
+		// it is subtracted from the result of the method.
+		recv := v
+		if n.Result {
+			recv = &ast.ParenExpr{X: &ast.UnaryExpr{Op: token.AND, X: v}}
+		}
+		return []ast.Stmt{s.add(&ast.BinaryExpr{X: call(selector(recv, n.Method)), Op: token.SUB, Y: s.sizeof(v)})}
+	}
+
+	depth++
+	switch n := plan.(type) {
+	case *StructCopy:
+		var stmts []ast.Stmt
+		for _, f := range n.Fields {
+			stmts = append(stmts, s.measure(selector(v, f.Name), f.Copy, sc, depth)...)
+		}
+		return stmts
+	case *ArrayCopy:
+		return s.elems(v, n.Elem, sc, depth)
+	case *SliceCopy:
+		// The backing array, by its capacity.
+		backing := s.add(&ast.BinaryExpr{X: call(ident("cap"), v), Op: token.MUL, Y: s.sizeof(index(v, "0"))})
+		return append([]ast.Stmt{backing}, s.elems(v, n.Elem, sc, depth)...)
+	case *MapCopy:
+		// The header of the map, and a slot and a control byte per entry.
+		t := n.Type.Underlying().(*types.Map)
+		slot := &ast.BinaryExpr{
+			X:  &ast.BinaryExpr{X: s.sizeof(s.zero(t.Key())), Op: token.ADD, Y: s.sizeof(s.zero(t.Elem()))},
+			Op: token.ADD,
+			Y:  &ast.BasicLit{Kind: token.INT, Value: "1"},
+		}
+		stmts := []ast.Stmt{ifNotNil(v, s.add(&ast.BinaryExpr{
+			X:  &ast.BasicLit{Kind: token.INT, Value: strconv.Itoa(rt.MapHeaderSize)},
+			Op: token.ADD,
+			Y:  &ast.BinaryExpr{X: call(ident("len"), v), Op: token.MUL, Y: &ast.ParenExpr{X: slot}},
+		}))}
+
+		loopScope := sc.inner()
+		key, val := loopScope.declare(indexed("k", depth)), loopScope.declare(indexed("v", depth))
+
+		bodyScope := loopScope.inner()
+		kb := s.measure(ident(key), n.Key, bodyScope, depth)
+		vb := s.measure(ident(val), n.Elem, bodyScope, depth)
+		if len(kb) == 0 && len(vb) == 0 {
+			return stmts
+		}
+
+		loop := &ast.RangeStmt{Key: ident(key), Tok: token.DEFINE, X: v, Body: &ast.BlockStmt{List: append(kb, vb...)}}
+		if len(kb) == 0 {
+			loop.Key = ident("_")
+		}
+		if len(vb) > 0 {
+			loop.Value = ident(val)
+		}
+		return append(stmts, loop)
+	case *PointerCopy:
+		elem, _ := deref(v, v, n.Elem)
+		body := []ast.Stmt{s.add(s.sizeof(&ast.StarExpr{X: v}))}
+		return []ast.Stmt{ifNotNil(v, append(body, s.measure(elem, n.Elem, sc.inner(), depth)...)...)}
+	default:
+		return nil
+	}
+}
+
+// elems returns the statements adding the sizes the elements of the array
+// or slice v reference, following the plan of the elements.
+func (s sizer) elems(v ast.Expr, elem Plan, sc *scope, depth int) []ast.Stmt {
+	loopScope := sc.inner()
+	idx := loopScope.declare(indexed("i", depth))
+
+	body := s.measure(index(v, idx), elem, loopScope.inner(), depth)
+	if len(body) == 0 {
+		return nil
+	}
+
+	return []ast.Stmt{&ast.RangeStmt{
+		Key:  ident(idx),
+		Tok:  token.DEFINE,
+		X:    v,
+		Body: &ast.BlockStmt{List: body},
+	}}
+}
+
+// add returns the statement adding n to the size variable.
+func (s sizer) add(n ast.Expr) ast.Stmt {
+	return &ast.AssignStmt{Lhs: []ast.Expr{s.size}, Tok: token.ADD_ASSIGN, Rhs: []ast.Expr{n}}
+}
+
+// sizeof returns the size of the value of v, as an int.
+func (s sizer) sizeof(v ast.Expr) ast.Expr {
+	return call(ident("int"), call(selector(ident(s.g.importPath("unsafe")), "Sizeof"), v))
+}
+
+// zero returns the zero value of the type, as *new(T), for its size to be
+// taken.
+func (s sizer) zero(t types.Type) ast.Expr {
+	return &ast.StarExpr{X: call(ident("new"), s.g.typeExpr(t, s.x))}
+}
+
+// hasSize reports whether the type has a size method, generated or existing,
+// and whether it has a pointer receiver.
+func (g Generator) hasSize(m methoder, generating []object) (hasMethod, isPointer bool) {
+	if types.IsInterface(m) {
+		return false, false
+	}
+
+	for _, t := range generating {
+		if types.Identical(m, t) {
+			return true, g.isPtrRecv
+		}
+	}
+
+	for i := 0; i < m.NumMethods(); i++ {
+		if f := m.Method(i); f.Name() == sizeMethodName {
+			return IsSizeMethod(f, sizeMethodName)
+		}
+	}
+
+	return false, false
+}
+
+// IsSizeMethod reports whether m is a size method with the given name: it
+// takes no parameters and returns an int. isPointer reports whether it has a
+// pointer receiver.
+func IsSizeMethod(m *types.Func, name string) (ok, isPointer bool) {
+	if m.Name() != name {
+		return false, false
+	}
+
+	sig, _ := m.Type().(*types.Signature)
+	if sig == nil || sig.Recv() == nil || sig.Params().Len() != 0 || sig.Results().Len() != 1 {
+		return false, false
+	}
+
+	if res, ok := sig.Results().At(0).Type().Underlying().(*types.Basic); !ok || res.Kind() != types.Int {
+		return false, false
+	}
+
+	_, recvPointer := reducePointer(sig.Recv().Type())
+	return true, recvPointer
+}
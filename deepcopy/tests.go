@@ -0,0 +1,283 @@
+package deepcopy
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// GenerateTests writes a test file for the package, exercising the deep copy
+// methods Generate produces for the given types. For every type, a table test
+// and a fuzz target fill a value with pseudo random data, copy it, check the
+// copy is equal to the original and that mutating the copy leaves the
+// original untouched. The fuzz target draws the data from the fuzzed bytes,
+// so that the fuzzer searches the values filled.
+func (g Generator) GenerateTests(w io.Writer, types []string, p *packages.Package) error {
+	if g.markers {
+		g.isPtrRecv = true
+	}
+
+	objs, _, err := g.locateTypes(types, p)
+	if err != nil {
+		return withPackageErrors(err, p)
+	}
+	if len(objs) == 0 {
+		return fmt.Errorf("no types to test in %q", p.Name)
+	}
+
+	var file bytes.Buffer
+
+	if err := g.writeHeader(&file, p, objs); err != nil {
+		return err
+	}
+
+	file.WriteString(`import (
+	"encoding/binary"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"unsafe"
+)
+
+`)
+
+	// The helpers are named after the first type, so that the test files
+	// of several runs can share a package.
+	tester := "deepCopyTester" + objs[0].Obj().Name()
+	newTester := "newDeepCopyTester" + objs[0].Obj().Name()
+
+	for i, obj := range objs {
+		g.writeTypeTest(&file, obj.Obj().Name(), tester, newTester, g.skipLists.Get(i))
+	}
+
+	file.WriteString(strings.NewReplacer("newTESTER", newTester, "TESTER", tester).Replace(testerSource))
+	fmt.Fprintf(&file, "\nconst %sPkgPath = %q\n\nconst %sMaxDepth = %d\n", tester, p.PkgPath, tester, g.maxDepth)
+
+	b, err := format.Source(file.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting source: %w", sourceError(err, file.Bytes(), 0))
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func (g Generator) writeTypeTest(w io.Writer, kind, tester, newTester string, skips skips) {
+	name := kind + g.methodName
+	helper := "test" + name
+
+	cp, mutated := "cp", "reflect.ValueOf(&cp).Elem()"
+	if g.isPtrRecv {
+		cp, mutated = "*cp", "reflect.ValueOf(cp).Elem()"
+	}
+
+	sels := make([]string, 0, len(skips))
+	for sel := range skips {
+		sels = append(sels, fmt.Sprintf("%q: {}", sel))
+	}
+	sort.Strings(sels)
+
+	fmt.Fprintf(w, `func Test%s(t *testing.T) {
+	for seed := int64(0); seed < 16; seed++ {
+		t.Run(strconv.FormatInt(seed, 10), func(t *testing.T) {
+			%s(t, func() rand.Source { return rand.NewSource(seed) })
+		})
+	}
+}
+
+func Fuzz%s(f *testing.F) {
+	f.Add([]byte{0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		%s(t, func() rand.Source { return &%sBytes{data: data} })
+	})
+}
+
+// %s checks the deep copy of a value filled with
+// the data the sources return, all drawing the same.
+func %s(t *testing.T, source func() rand.Source) {
+	var orig, want %s
+	%s(source(), nil).fill(reflect.ValueOf(&orig).Elem(), 0)
+	%s(source(), nil).fill(reflect.ValueOf(&want).Elem(), 0)
+
+	cp := orig.%s()
+	if !reflect.DeepEqual(orig, %s) {
+		t.Fatalf("copy differs from the original:\n%%#v\n%%#v", orig, %s)
+	}
+
+	%s(source(), map[string]struct{}{%s}).mutate(%s, "", 0)
+	if !reflect.DeepEqual(orig, want) {
+		t.Fatalf("mutating the copy changed the original:\n%%#v\n%%#v", orig, want)
+	}
+}
+
+`, name, helper, name, helper, tester, helper, helper, kind, newTester, newTester, g.methodName, cp, cp, newTester, strings.Join(sels, ", "), mutated)
+}
+
+// testerSource fills and mutates values by reflection. Unexported fields are
+// accessed through unsafe, since the tests live in the package of the types.
+const testerSource = `// TESTER fills values with pseudo random data, and mutates every value a
+// deep copy owns, leaving out skipped selectors.
+type TESTER struct {
+	rnd   *rand.Rand
+	skips map[string]struct{}
+}
+
+func newTESTER(source rand.Source, skips map[string]struct{}) *TESTER {
+	return &TESTER{rnd: rand.New(source), skips: skips}
+}
+
+// TESTERBytes is a rand.Source drawing from fuzzed bytes, eight at a time,
+// and zeros past them.
+type TESTERBytes struct {
+	data []byte
+}
+
+func (s *TESTERBytes) Int63() int64 {
+	var b [8]byte
+	n := copy(b[:], s.data)
+	s.data = s.data[n:]
+	return int64(binary.BigEndian.Uint64(b[:]) >> 1)
+}
+
+func (s *TESTERBytes) Seed(int64) {}
+
+func (tt *TESTER) settable(v reflect.Value) reflect.Value {
+	if v.CanSet() {
+		return v
+	}
+
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// fill populates the addressable value v. Channels, functions and interfaces
+// are left nil.
+func (tt *TESTER) fill(v reflect.Value, depth int) {
+	v = tt.settable(v)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(tt.rnd.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(tt.rnd.Int63n(100) + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(uint64(tt.rnd.Int63n(100) + 1))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(tt.rnd.Int63n(100)) + 0.5)
+	case reflect.Complex64, reflect.Complex128:
+		v.SetComplex(complex(float64(tt.rnd.Int63n(100)), 1))
+	case reflect.String:
+		v.SetString(string(rune('a' + tt.rnd.Intn(26))))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			tt.fill(v.Index(i), depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			tt.fill(v.Field(i), depth+1)
+		}
+	case reflect.Pointer:
+		if depth < 8 {
+			p := reflect.New(v.Type().Elem())
+			tt.fill(p.Elem(), depth+1)
+			v.Set(p)
+		}
+	case reflect.Slice:
+		if depth < 8 {
+			n := 1 + tt.rnd.Intn(3)
+			s := reflect.MakeSlice(v.Type(), n, n)
+			for i := 0; i < n; i++ {
+				tt.fill(s.Index(i), depth+1)
+			}
+			v.Set(s)
+		}
+	case reflect.Map:
+		if depth < 8 {
+			n := 1 + tt.rnd.Intn(3)
+			m := reflect.MakeMapWithSize(v.Type(), n)
+			for i := 0; i < n; i++ {
+				k := reflect.New(v.Type().Key()).Elem()
+				tt.fill(k, depth+1)
+				e := reflect.New(v.Type().Elem()).Elem()
+				tt.fill(e, depth+1)
+				m.SetMapIndex(k, e)
+			}
+			v.Set(m)
+		}
+	}
+}
+
+// mutate changes every value reachable from v, which sel selects, unless
+// the deep copy shares it with the original.
+func (tt *TESTER) mutate(v reflect.Value, sel string, depth int) {
+	if _, ok := tt.skips[sel]; ok {
+		return
+	}
+	if TESTERMaxDepth > 0 && depth >= TESTERMaxDepth {
+		return
+	}
+
+	v = tt.settable(v)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(!v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v.SetUint(v.Uint() + 1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(v.Float() + 1)
+	case reflect.Complex64, reflect.Complex128:
+		v.SetComplex(v.Complex() + 1)
+	case reflect.String:
+		v.SetString(v.String() + "~")
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			tt.mutate(v.Index(i), sel+"[i]", depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.PkgPath != "" && f.PkgPath != TESTERPkgPath {
+				// Unexported fields of foreign types are never copied.
+				continue
+			}
+
+			fsel := f.Name
+			if sel != "" {
+				fsel = sel + "." + f.Name
+			}
+			tt.mutate(v.Field(i), fsel, depth+1)
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			tt.mutate(v.Elem(), sel, depth+1)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			tt.mutate(v.Index(i), sel+"[i]", depth+1)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+
+		for _, k := range v.MapKeys() {
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			tt.mutate(e, sel+"[k]", depth+1)
+			v.SetMapIndex(k, e)
+		}
+
+		k := reflect.New(v.Type().Key()).Elem()
+		tt.fill(k, depth+1)
+		v.SetMapIndex(k, reflect.Zero(v.Type().Elem()))
+	}
+}
+`
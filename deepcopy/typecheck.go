@@ -0,0 +1,228 @@
+package deepcopy
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// typeCheck type-checks the generated source together with the files of the
+// package, leaving out the generated files it replaces. Only the errors
+// located in the generated source are reported, mapped back to the generated
+// type and field selector. The selectors are the ones of the expressions
+// rooted at the locals named roots. Packages loaded without syntax are not
+// checked.
+func typeCheck(p *packages.Package, src []byte, roots []string) error {
+	if len(p.Syntax) == 0 {
+		return nil
+	}
+
+	dir := "."
+	if len(p.CompiledGoFiles) > 0 {
+		dir = filepath.Dir(p.CompiledGoFiles[0])
+	}
+
+	// The package file set is shared with the loaded syntax, so that the
+	// positions of all files are comparable.
+	name := filepath.Join(dir, "deep-copy.generated.go")
+	f, err := parser.ParseFile(p.Fset, name, src, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("parsing generated source: %w", err)
+	}
+
+	methods := declaredMethods(f)
+	files := make([]*ast.File, 0, len(p.Syntax)+1)
+	for _, file := range p.Syntax {
+		if !replaces(methods, file) {
+			files = append(files, file)
+		}
+	}
+	// The generated file goes last, so that redeclarations are reported in it.
+	files = append(files, f)
+
+	var errs []error
+	conf := types.Config{
+		Importer: packageImporter(p, dir),
+		Error: func(err error) {
+			terr, ok := err.(types.Error)
+			if !ok || terr.Pos < f.FileStart || terr.Pos > f.FileEnd {
+				return
+			}
+
+			errs = append(errs, fmt.Errorf("%s: %s", describePos(f, terr.Pos, roots), terr.Msg))
+		},
+	}
+
+	_, _ = conf.Check(p.PkgPath, p.Fset, files, nil)
+
+	return errors.Join(errs...)
+}
+
+// declaredMethods returns the receiver type and name of the methods the file
+// declares, and the name of its functions, with no receiver type.
+func declaredMethods(f *ast.File) map[[2]string]bool {
+	methods := map[[2]string]bool{}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if fn.Recv == nil {
+			methods[[2]string{"", fn.Name.Name}] = true
+			continue
+		}
+		if len(fn.Recv.List) != 1 {
+			continue
+		}
+
+		recv := strings.TrimPrefix(types.ExprString(fn.Recv.List[0].Type), "*")
+		methods[[2]string{recv, fn.Name.Name}] = true
+	}
+
+	return methods
+}
+
+// replaces reports whether the file is a generated one, declaring some of the
+// methods, most likely a previous output of deep-copy.
+func replaces(methods map[[2]string]bool, f *ast.File) bool {
+	if !ast.IsGenerated(f) {
+		return false
+	}
+
+	for m := range declaredMethods(f) {
+		if methods[m] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// packageImporter imports the dependencies of the package from its loaded
+// import graph. The packages only the generated source imports are imported by
+// the default importer, or else from their export data, as resolved from the
+// directory of the package.
+func packageImporter(p *packages.Package, dir string) types.Importer {
+	pkgs := map[string]*types.Package{}
+
+	var collect func(*types.Package)
+	collect = func(pkg *types.Package) {
+		for _, imp := range pkg.Imports() {
+			if _, ok := pkgs[imp.Path()]; !ok {
+				pkgs[imp.Path()] = imp
+				collect(imp)
+			}
+		}
+	}
+	collect(p.Types)
+
+	fallback := importer.Default()
+	return importerFunc(func(path string) (*types.Package, error) {
+		if pkg, ok := pkgs[path]; ok {
+			return pkg, nil
+		}
+
+		pkg, err := fallback.Import(path)
+		if err != nil {
+			pkg, err = importFromDir(p.Fset, dir, path)
+		}
+		if err == nil {
+			pkgs[path] = pkg
+		}
+		return pkg, err
+	})
+}
+
+// importFromDir imports the package at path, as resolved from dir, from its
+// export data.
+func importFromDir(fset *token.FileSet, dir, path string) (*types.Package, error) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps | packages.NeedExportFile,
+		Dir:  dir,
+	}, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 || len(pkgs[0].Errors) > 0 {
+		return nil, fmt.Errorf("can't load %s from %s", path, dir)
+	}
+
+	// The package is imported as the only dependency of a package stub.
+	stub := &packages.Package{Imports: map[string]*packages.Package{path: pkgs[0]}}
+	return exportDataImporter(fset, stub).Import(path)
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+var indexRE = regexp.MustCompile(`\[i\d*_*\]`)
+
+// describePos names the generated method and, when known, the field selector
+// whose copy contains pos, selected from one of the roots.
+func describePos(f *ast.File, pos token.Pos, roots []string) string {
+	var (
+		kind, method string
+		sel          string
+	)
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || pos > n.End() {
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			method = n.Name.Name
+			if n.Recv != nil && len(n.Recv.List) == 1 {
+				kind = types.ExprString(n.Recv.List[0].Type)
+			}
+		case *ast.AssignStmt:
+			if s, ok := selectorOf(n.Lhs[0], roots); ok {
+				sel = s
+			}
+		case *ast.IfStmt:
+			if bin, ok := n.Cond.(*ast.BinaryExpr); ok {
+				if s, ok := selectorOf(bin.X, roots); ok {
+					sel = s
+				}
+			}
+		}
+
+		return true
+	})
+
+	desc := fmt.Sprintf("type %s", strings.TrimPrefix(kind, "*"))
+	if method != "" {
+		desc += ", method " + method
+	}
+	if sel != "" {
+		desc += ", field " + sel
+	}
+
+	return desc
+}
+
+// selectorOf returns the skip selector of the expression, if it selects a
+// member of one of the roots.
+func selectorOf(e ast.Expr, roots []string) (string, bool) {
+	s := strings.TrimPrefix(types.ExprString(e), "*")
+	for _, root := range roots {
+		if sel, ok := strings.CutPrefix(s, root+"."); ok {
+			return indexRE.ReplaceAllString(sel, "[i]"), true
+		}
+	}
+
+	return "", false
+}
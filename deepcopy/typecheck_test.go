@@ -0,0 +1,110 @@
+package deepcopy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestTypeCheck(t *testing.T) {
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports,
+	}, "../testdata/shadow")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		roots   []string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			src: `package shadow
+
+func (o_ Shadowed) DeepCopy() Shadowed {
+	var cp Shadowed = o_
+	return cp
+}`,
+		},
+		{
+			name: "field error",
+			src: `package shadow
+
+func (o_ Shadowed) DeepCopy() Shadowed {
+	var cp Shadowed = o_
+	if o_.R != nil {
+		cp.R = make([]retV, len(o_.R))
+		for i2 := range o_.R {
+			cp.R[i2] = o_.R[i2].DeepCopy()
+		}
+	}
+	return cp
+}`,
+			roots:   []string{"cp", "o_"},
+			wantErr: "type Shadowed, method DeepCopy, field R[i]: cannot use o_.R[i2].DeepCopy() (value of type *retV) as retV value in assignment",
+		},
+		{
+			name: "other roots",
+			src: `package shadow
+
+func (src Shadowed) DeepCopy() Shadowed {
+	var dst Shadowed = src
+	if src.R != nil {
+		dst.R = make([]retV, len(src.R))
+		for i2 := range src.R {
+			dst.R[i2] = src.R[i2].DeepCopy()
+		}
+	}
+	return dst
+}`,
+			roots:   []string{"dst", "src"},
+			wantErr: "type Shadowed, method DeepCopy, field R[i]: cannot use src.R[i2].DeepCopy() (value of type *retV) as retV value in assignment",
+		},
+		{
+			name: "not a root",
+			src: `package shadow
+
+func (src Shadowed) DeepCopy() Shadowed {
+	var dst Shadowed = src
+	if src.R != nil {
+		dst.R = make([]retV, len(src.R))
+		for i2 := range src.R {
+			dst.R[i2] = src.R[i2].DeepCopy()
+		}
+	}
+	return dst
+}`,
+			roots:   []string{"cp", "o"},
+			wantErr: "type Shadowed, method DeepCopy: cannot use src.R[i2].DeepCopy() (value of type *retV) as retV value in assignment",
+		},
+		{
+			name: "shadowed type",
+			src: `package shadow
+
+func (o Shadowed) DeepCopy() Shadowed {
+	var cp Shadowed = o
+	if o.O != nil {
+		cp.O = new(o)
+	}
+	return cp
+}`,
+			roots:   []string{"cp", "o_"},
+			wantErr: "type Shadowed, method DeepCopy, field O: cannot use new(o) (value of type *Shadowed) as *o value in assignment",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := typeCheck(pkgs[0], []byte(tt.src), tt.roots)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -7,6 +7,42 @@
 // Multiple types can be specified for the given package, by adding more --type
 // parameters.
 //
+// The --all flag selects every exported named struct type of the package, and
+// the --type-pattern flag the named struct types matching a regular
+// expression. Types can be left out of them with --exclude-type, and types with
+// a hand-written deep copy method are skipped.
+//
+// The --tests flag also loads the _test.go files of the package, generating the
+// types they declare into a _test.go output file.
+//
+// The package is loaded for the host platform without build tags, unless the
+// --load-tags, --goos and --goarch flags are given. The generated file then
+// gets the matching build constraint, unless --tags sets one.
+//
+// The --overlay flag loads the package with files replaced as described by a
+// JSON file in the format of go build -overlay.
+//
+// The --export-data flag type-checks only the package, importing its
+// dependencies from export data. The --cache-dir flag skips regenerating
+// packages whose files and dependencies did not change, and --timing reports
+// the time spent loading versus generating.
+//
+// Several package paths or patterns generate every matching package, into the
+// file named by -o in the directory of each package, concurrently on -j
+// workers. The --manifest flag writes a JSON file listing the generated files
+// with their package, types, options, inputs and hash, and the warnings of the
+// run.
+//
+// The --header-file flag, or the header entry of the configuration file, gives
+// a text/template of a banner, as in a license, written at the top of the
+// generated files. It is executed with the package name, the types and the
+// version of the tool, and the generated code marker is kept after it.
+//
+// The previous output file, when generated, is left out of the package while
+// loading it, so that a stale output does not prevent regenerating it. Errors
+// of the package are reported along with the generation errors they likely
+// cause, and as warnings otherwise.
+//
 // To specify a pointer receiver for the method, an optional --pointer-receiver
 // boolean flag can be specified. The flag will also govern whether the return
 // type is a pointer as well.
@@ -15,4 +51,39 @@
 // members, or map members. To achieve that, selectors can be specified in the
 // optional comma-separated --skip flag. Multiple --skip flags can be
 // specified, to match the number of --type flags.
+//
+// Slices and maps whose elements need no deep copy are copied with slices.Clone
+// and maps.Clone when the go directive of the package's module allows it. The
+// --go-version flag sets the minimum Go version of the generated code instead.
+//
+// The --markers flag selects the types from +k8s:deepcopy-gen markers instead,
+// generating DeepCopyInto, DeepCopy and interface methods compatible with
+// zz_generated.deepcopy.go files.
+//
+// The --emit-tests flag also writes a _deepcopy_test.go file next to the output
+// file, asserting that the generated methods copy every pointer, slice and map.
+//
+// The --equal flag also generates an Equal method on every type, comparing
+// what the deep copy method copies and calling existing Equal methods. Nil and
+// empty slices and maps are equal with --equal-nil-empty.
+//
+// The --diff flag also generates a Diff method on every type, returning the
+// []deepcopy.Change between two values, with the selectors of the changed
+// members in the syntax of --skip.
+//
+// The --size flag also generates a DeepSize method on every type, estimating
+// the memory the value and its deep copy hold, skipped members left out.
+//
+// The --merge flag also generates a MergeFrom method on every type, overlaying
+// the non-zero members of another value, deep copied. The merge struct tag
+// sets the policy of a field: "replace", "append" to a slice, or "-" to keep.
+//
+// The --convert flag generates a function converting a struct type to another,
+// as in --convert domain.Order:api.Order, matching their fields by name or by
+// a deepcopy:"name=..." struct tag, and warning about the unmatched ones.
+//
+// The explain subcommand, as in deep-copy explain --type T ./pkg, takes the
+// same flags and prints how every member of the types would be copied,
+// instead of generating them. The --json flag prints it as JSON. Both follow
+// the copy plan returned by Generator.CopyPlans in package deepcopy.
 package main
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/globusdigital/deep-copy/deepcopy"
+)
+
+// explain prints the copy plans of the types of the package at path, as text
+// or as JSON if asJSON is set.
+func explain(g deepcopy.Generator, w io.Writer, path string, types typesVal, cfg deepcopy.LoadConfig, asJSON bool) error {
+	pkgs, err := deepcopy.Load(cfg, path)
+	if err != nil {
+		return fmt.Errorf("loading package: %v", err)
+	}
+	if len(pkgs) == 0 {
+		return errors.New("no package found")
+	}
+
+	p := pkgs[0]
+	if cfg.Tests {
+		p, err = g.TestVariant(pkgs, types)
+		if err != nil {
+			return err
+		}
+	}
+
+	explanations, err := g.Explain(types, p)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(explanations)
+	}
+
+	for _, e := range explanations {
+		writeExplanation(w, e, 0)
+	}
+
+	return nil
+}
+
+// writeExplanation writes the copy plan of a member as a line, followed by
+// the ones of its members, indented.
+func writeExplanation(w io.Writer, e deepcopy.Explanation, indent int) {
+	name := e.Selector
+	if name == "" {
+		name = e.Type
+	} else {
+		name += " " + e.Type
+	}
+	if e.Key {
+		name += " (key)"
+	}
+
+	line := fmt.Sprintf("%s%s: %s", strings.Repeat("  ", indent), name, e.Copy)
+	if e.Reason != "" {
+		line += ", " + e.Reason
+	}
+	fmt.Fprintln(w, line)
+
+	for _, m := range e.Members {
+		writeExplanation(w, m, indent+1)
+	}
+}
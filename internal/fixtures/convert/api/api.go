@@ -0,0 +1,39 @@
+// Package api holds the API types the convert fixture converts to.
+package api
+
+import "time"
+
+type Status string
+
+type Order struct {
+	ID       int
+	Customer *Customer
+	Lines    []Line `deepcopy:"name=Items"`
+	Tags     map[string][]string
+	Status   Status
+	Placed   time.Time
+	Version  string
+}
+
+type Customer struct {
+	Name      string
+	Email     string
+	Addresses []*Address
+	Link      string `deepcopy:"-"`
+}
+
+type Address struct {
+	Street string
+	City   string
+}
+
+type Line struct {
+	SKU      string
+	Quantity int
+	Options  map[string]Option
+}
+
+type Option struct {
+	Value string
+	Extra []string
+}
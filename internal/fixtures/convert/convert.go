@@ -0,0 +1,16 @@
+// Package convert holds converters between the domain and API types, whose
+// generated code is checked in, so that it is compiled and run by go test.
+package convert
+
+import (
+	"github.com/globusdigital/deep-copy/internal/fixtures/convert/api"
+	"github.com/globusdigital/deep-copy/internal/fixtures/convert/domain"
+)
+
+//go:generate go run ../../.. -o convert_deepcopy.go --convert domain.Order:api.Order --convert domain.Customer:api.Customer .
+
+// StatusFromAPI converts a status back, the way the generated converters
+// convert it to the API.
+func StatusFromAPI(s api.Status) domain.Status {
+	return domain.Status(s)
+}
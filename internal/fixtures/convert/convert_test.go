@@ -0,0 +1,61 @@
+package convert
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/globusdigital/deep-copy/deepcopy/rt"
+	"github.com/globusdigital/deep-copy/internal/fixtures/convert/api"
+	"github.com/globusdigital/deep-copy/internal/fixtures/convert/domain"
+)
+
+func TestConvertOrder(t *testing.T) {
+	placed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	src := domain.Order{
+		ID: 1,
+		Customer: &domain.Customer{
+			Name:      "alice",
+			Email:     "alice@example.com",
+			Addresses: []*domain.Address{{Street: "Main St", City: "Springfield"}, nil},
+		},
+		Items: []domain.Item{
+			{SKU: "a", Quantity: 2, Options: map[string]domain.Option{"color": {Value: "red", Extra: []string{"matte"}}}},
+			{SKU: "b"},
+		},
+		Tags:   map[string][]string{"channel": {"web"}},
+		Status: "paid",
+		Placed: placed,
+		Notes:  "left out",
+	}
+
+	want := api.Order{
+		ID: 1,
+		Customer: &api.Customer{
+			Name:      "alice",
+			Email:     "alice@example.com",
+			Addresses: []*api.Address{{Street: "Main St", City: "Springfield"}, nil},
+		},
+		Lines: []api.Line{
+			{SKU: "a", Quantity: 2, Options: map[string]api.Option{"color": {Value: "red", Extra: []string{"matte"}}}},
+			{SKU: "b"},
+		},
+		Tags:   map[string][]string{"channel": {"web"}},
+		Status: "paid",
+		Placed: placed,
+	}
+
+	got := ConvertOrder(src)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ConvertOrder() =\n%#v\nwant\n%#v", got, want)
+	}
+	if err := rt.NoAlias(src, got); err != nil {
+		t.Errorf("ConvertOrder() aliased the source: %v", err)
+	}
+}
+
+func TestConvertOrderZero(t *testing.T) {
+	if got := ConvertOrder(domain.Order{}); !reflect.DeepEqual(got, api.Order{}) {
+		t.Errorf("ConvertOrder() of the zero value = %#v, want the zero value", got)
+	}
+}
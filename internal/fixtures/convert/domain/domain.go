@@ -0,0 +1,39 @@
+// Package domain holds the domain types the convert fixture converts from.
+package domain
+
+import "time"
+
+type Status string
+
+type Order struct {
+	ID       int
+	Customer *Customer
+	Items    []Item
+	Tags     map[string][]string
+	Status   Status
+	Placed   time.Time
+	Notes    string
+	revision int
+}
+
+type Customer struct {
+	Name      string
+	Email     string
+	Addresses []*Address
+}
+
+type Address struct {
+	Street string
+	City   string
+}
+
+type Item struct {
+	SKU      string
+	Quantity int
+	Options  map[string]Option
+}
+
+type Option struct {
+	Value string
+	Extra []string
+}
@@ -0,0 +1,49 @@
+// Package ledger holds types whose generated deep copy and equality methods
+// are checked in, so that the generated code is compiled and run by go test.
+package ledger
+
+import (
+	"math/big"
+	"net/netip"
+	"time"
+)
+
+//go:generate go run ../../.. -o ledger_deepcopy.go --equal --diff --type Account --type Entry --skip Cache .
+
+type Account struct {
+	ID      int
+	Owner   *string
+	Balance Money
+	Opened  time.Time
+	Entries []Entry
+	Limits  map[string]*Money
+	Labels  map[string][]string
+	Meta    any
+	Parent  *Account
+	Cache   []byte
+	notify  chan struct{}
+	Host    netip.Addr
+	Credit  big.Int
+	Debt    *big.Int
+}
+
+type Entry struct {
+	Amount Money
+	Memo   *string
+	Codes  [2]string
+}
+
+type Money struct {
+	Units    int64
+	Currency string
+}
+
+// Equal reports whether the amounts are equal, zero amounts being equal
+// whatever their currency.
+func (m Money) Equal(other Money) bool {
+	if m.Units == 0 && other.Units == 0 {
+		return true
+	}
+
+	return m == other
+}
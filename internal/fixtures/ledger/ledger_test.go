@@ -0,0 +1,121 @@
+package ledger
+
+import (
+	"math/big"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAccount() Account {
+	owner, memo := "alice", "rent"
+
+	return Account{
+		ID:      1,
+		Owner:   &owner,
+		Balance: Money{Units: 100, Currency: "EUR"},
+		Opened:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Entries: []Entry{{Amount: Money{Units: -50, Currency: "EUR"}, Memo: &memo, Codes: [2]string{"a", "b"}}},
+		Limits:  map[string]*Money{"daily": {Units: 10, Currency: "EUR"}, "none": nil},
+		Labels:  map[string][]string{"kind": {"checking"}},
+		Meta:    map[string]int{"score": 3},
+		Parent:  &Account{ID: 2},
+		Cache:   []byte("cached"),
+		Host:    netip.MustParseAddr("10.0.0.1"),
+		Credit:  *big.NewInt(1000),
+		Debt:    big.NewInt(7),
+	}
+}
+
+func TestAccountEqual(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Account)
+		want   bool
+	}{
+		{name: "copy", mutate: func(*Account) {}, want: true},
+		{name: "skipped field", mutate: func(a *Account) { a.Cache = nil }, want: true},
+		{name: "pointed to value", mutate: func(a *Account) { *a.Owner = "bob" }, want: false},
+		{name: "nil pointer", mutate: func(a *Account) { a.Owner = nil }, want: false},
+		{name: "existing Equal method", mutate: func(a *Account) { a.Balance.Units = 99 }, want: false},
+		{name: "existing Equal method, equal values", mutate: func(a *Account) { a.Parent.Balance = Money{Currency: "USD"} }, want: true},
+		{name: "time in another location", mutate: func(a *Account) { a.Opened = a.Opened.In(time.FixedZone("X", 3600)) }, want: true},
+		{name: "slice element", mutate: func(a *Account) { *a.Entries[0].Memo = "food" }, want: false},
+		{name: "array element", mutate: func(a *Account) { a.Entries[0].Codes[1] = "c" }, want: false},
+		{name: "empty slice", mutate: func(a *Account) { a.Entries = a.Entries[:0] }, want: false},
+		{name: "map value", mutate: func(a *Account) { a.Limits["daily"].Units = 20 }, want: false},
+		{name: "map key", mutate: func(a *Account) { delete(a.Limits, "none"); a.Limits["other"] = nil }, want: false},
+		{name: "nil and empty map", mutate: func(a *Account) { a.Labels["kind"] = nil }, want: false},
+		{name: "interface", mutate: func(a *Account) { a.Meta = map[string]int{"score": 4} }, want: false},
+		{name: "recursive type", mutate: func(a *Account) { a.Parent.ID = 3 }, want: false},
+		{name: "opaque comparable value", mutate: func(a *Account) { a.Host = netip.MustParseAddr("10.0.0.2") }, want: false},
+		{name: "opaque value", mutate: func(a *Account) { a.Credit = *big.NewInt(1001) }, want: false},
+		{name: "opaque pointed to value", mutate: func(a *Account) { a.Debt = big.NewInt(8) }, want: false},
+		{name: "opaque pointed to equal value", mutate: func(a *Account) { a.Debt = big.NewInt(7) }, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := newAccount()
+			cp := orig.DeepCopy()
+			tt.mutate(&cp)
+
+			if got := orig.Equal(cp); got != tt.want {
+				t.Errorf("Equal() = %v, want %v", got, tt.want)
+			}
+			if got := cp.Equal(orig); got != tt.want {
+				t.Errorf("Equal() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccountDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Account)
+		want   []string
+	}{
+		{name: "copy", mutate: func(*Account) {}},
+		{name: "skipped field", mutate: func(a *Account) { a.Cache = nil }},
+		{name: "field", mutate: func(a *Account) { a.ID = 3 }, want: []string{"ID: 1 -> 3"}},
+		{name: "pointed to value", mutate: func(a *Account) { *a.Owner = "bob" }, want: []string{"Owner: alice -> bob"}},
+		{name: "existing Equal method", mutate: func(a *Account) { a.Balance.Units = 99 }, want: []string{"Balance: {100 EUR} -> {99 EUR}"}},
+		{name: "existing Equal method, equal values", mutate: func(a *Account) { a.Parent.Balance = Money{Currency: "USD"} }},
+		{name: "slice element", mutate: func(a *Account) { *a.Entries[0].Memo = "food" }, want: []string{"Entries[0].Memo: rent -> food"}},
+		{name: "array element", mutate: func(a *Account) { a.Entries[0].Codes[1] = "c" }, want: []string{"Entries[0].Codes[1]: b -> c"}},
+		{name: "removed slice element", mutate: func(a *Account) { a.Entries = a.Entries[:0] }, want: []string{"Entries[0]: removed {{-50 EUR} 0x"}},
+		{name: "added slice element", mutate: func(a *Account) { a.Entries = append(a.Entries, Entry{}) }, want: []string{"Entries[1]: added {{0 } <nil> [ ]}"}},
+		{name: "map value", mutate: func(a *Account) { a.Limits["daily"] = &Money{Units: 20} }, want: []string{`Limits["daily"]: &{10 EUR} -> &{20 }`}},
+		{name: "added map entry", mutate: func(a *Account) { a.Limits["weekly"] = nil }, want: []string{`Limits["weekly"]: added <nil>`}},
+		{name: "removed map entry", mutate: func(a *Account) { delete(a.Limits, "none") }, want: []string{`Limits["none"]: removed <nil>`}},
+		{name: "nested slice element", mutate: func(a *Account) { a.Labels["kind"][0] = "savings" }, want: []string{`Labels["kind"][0]: checking -> savings`}},
+		{name: "nil and empty map", mutate: func(a *Account) { a.Labels["kind"] = nil }, want: []string{`Labels["kind"][0]: removed checking`}},
+		{name: "recursive type", mutate: func(a *Account) { a.Parent.ID = 3 }, want: []string{"Parent.ID: 2 -> 3"}},
+		{name: "map entries in key order", mutate: func(a *Account) {
+			a.Limits["z"], a.Limits["a"] = nil, nil
+			delete(a.Limits, "daily")
+		}, want: []string{`Limits["a"]: added`, `Limits["daily"]: removed`, `Limits["z"]: added`}},
+		{name: "opaque comparable value", mutate: func(a *Account) { a.Host = netip.MustParseAddr("10.0.0.2") }, want: []string{"Host: 10.0.0.1 -> 10.0.0.2"}},
+		{name: "opaque value", mutate: func(a *Account) { a.Credit = *big.NewInt(1001) }, want: []string{"Credit: "}},
+		{name: "opaque pointed to value", mutate: func(a *Account) { a.Debt = big.NewInt(8) }, want: []string{"Debt: "}},
+		{name: "opaque pointed to equal value", mutate: func(a *Account) { a.Debt = big.NewInt(7) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orig := newAccount()
+			cp := orig.DeepCopy()
+			tt.mutate(&cp)
+
+			changes := orig.Diff(cp)
+			if len(changes) != len(tt.want) {
+				t.Fatalf("Diff() = %v, want %v", changes, tt.want)
+			}
+			for i, c := range changes {
+				if got := c.String(); !strings.HasPrefix(got, tt.want[i]) {
+					t.Errorf("Diff()[%d] = %q, want %q", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
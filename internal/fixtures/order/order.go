@@ -0,0 +1,37 @@
+// Package order holds types whose generated deep copy methods and tests are
+// checked in, so that the generated code is compiled and run by go test.
+package order
+
+//go:generate go run ../../.. -o order_deepcopy.go --emit-tests --size --type Order --type Customer --skip Notes .
+
+type Order struct {
+	ID       int
+	Customer *Customer
+	Lines    []Line
+	Tags     map[string][]string
+	Parcels  map[string]Line
+	Notes    []*string
+	Events   chan string
+	total    *float64
+}
+
+type Line struct {
+	SKU      string
+	Quantity int
+	Options  map[string]*Option
+}
+
+type Option struct {
+	Name  string
+	Price *float64
+}
+
+type Customer struct {
+	Name      string
+	Addresses []*Address
+}
+
+type Address struct {
+	Street string
+	Zip    *string
+}
@@ -0,0 +1,27 @@
+package order
+
+import (
+	"reflect"
+	"testing"
+)
+
+// The copies of struct map values start as the values, so that the fields
+// that are not deep copied into them are kept.
+func TestOrderDeepCopyStructMapValues(t *testing.T) {
+	price := 2.5
+	o := Order{
+		Parcels: map[string]Line{
+			"box": {SKU: "sku-1", Quantity: 3, Options: map[string]*Option{"gift": {Name: "wrap", Price: &price}}},
+			"bag": {SKU: "sku-2", Quantity: 1},
+		},
+	}
+
+	cp := o.DeepCopy()
+	if !reflect.DeepEqual(cp.Parcels, o.Parcels) {
+		t.Fatalf("DeepCopy() Parcels = %#v, want %#v", cp.Parcels, o.Parcels)
+	}
+
+	if cp.Parcels["box"].Options["gift"] == o.Parcels["box"].Options["gift"] {
+		t.Error("DeepCopy() shares the options of the parcels")
+	}
+}
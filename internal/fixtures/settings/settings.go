@@ -0,0 +1,50 @@
+// Package settings holds layered configuration types whose generated deep
+// copy and merge methods are checked in, so that the generated code is
+// compiled and run by go test.
+package settings
+
+import "time"
+
+//go:generate go run ../../.. -o settings_deepcopy.go --merge --type Config --type Server --skip Revision,Proxy.Cache,Mirrors[i].Cache .
+
+type Config struct {
+	Name     string
+	Debug    *bool
+	Server   Server
+	Backup   *Server
+	Replicas [2]Server
+	Plugins  []string `merge:"append"`
+	Hosts    []string
+	Limits   map[string]*Limit
+	Env      map[string]string `merge:"replace"`
+	Labels   map[string][]string
+	Started  time.Time
+	Timeout  time.Duration
+	Secret   string `merge:"-"`
+	Extra    any
+	Revision int
+	Proxy    *Proxy `merge:"replace"`
+	Mirrors  []Proxy
+}
+
+type Server struct {
+	Host string
+	Port int
+	TLS  *TLS
+}
+
+type TLS struct {
+	Cert string
+	Key  []byte
+}
+
+type Limit struct {
+	Rate  int
+	Burst int
+}
+
+type Proxy struct {
+	URL     string
+	Headers map[string]string
+	Cache   []byte
+}
@@ -0,0 +1,133 @@
+package settings
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/globusdigital/deep-copy/deepcopy/rt"
+)
+
+func newBase() Config {
+	debug := false
+
+	return Config{
+		Name:     "base",
+		Debug:    &debug,
+		Server:   Server{Host: "localhost", Port: 80},
+		Backup:   &Server{Host: "backup", Port: 81, TLS: &TLS{Cert: "base.crt"}},
+		Plugins:  []string{"auth"},
+		Hosts:    []string{"a", "b"},
+		Limits:   map[string]*Limit{"api": {Rate: 10, Burst: 20}, "web": {Rate: 1}},
+		Env:      map[string]string{"HOME": "/root", "TERM": "xterm"},
+		Labels:   map[string][]string{"tier": {"base"}},
+		Started:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Timeout:  time.Second,
+		Secret:   "base",
+		Revision: 1,
+	}
+}
+
+func newOverlay() Config {
+	debug := true
+
+	return Config{
+		Debug:    &debug,
+		Server:   Server{Port: 8080, TLS: &TLS{Cert: "srv.crt", Key: []byte("key")}},
+		Backup:   &Server{Port: 9090, TLS: &TLS{Key: []byte("backup")}},
+		Replicas: [2]Server{{}, {Host: "replica"}},
+		Plugins:  []string{"metrics"},
+		Hosts:    []string{"c"},
+		Limits:   map[string]*Limit{"api": {Rate: 5}, "db": nil},
+		Env:      map[string]string{"PATH": "/bin"},
+		Labels:   map[string][]string{"env": {"prod"}},
+		Secret:   "overlay",
+		Extra:    "extra",
+		Revision: 2,
+	}
+}
+
+func TestConfigMergeFrom(t *testing.T) {
+	debug := true
+	want := Config{
+		Name:     "base",
+		Debug:    &debug,
+		Server:   Server{Host: "localhost", Port: 8080, TLS: &TLS{Cert: "srv.crt", Key: []byte("key")}},
+		Backup:   &Server{Host: "backup", Port: 9090, TLS: &TLS{Cert: "base.crt", Key: []byte("backup")}},
+		Replicas: [2]Server{{}, {Host: "replica"}},
+		Plugins:  []string{"auth", "metrics"},
+		Hosts:    []string{"c"},
+		Limits:   map[string]*Limit{"api": {Rate: 5}, "web": {Rate: 1}, "db": nil},
+		Env:      map[string]string{"PATH": "/bin"},
+		Labels:   map[string][]string{"tier": {"base"}, "env": {"prod"}},
+		Started:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Timeout:  time.Second,
+		Secret:   "base",
+		Extra:    "extra",
+		Revision: 1,
+	}
+
+	base, overlay := newBase(), newOverlay()
+	got := base.DeepCopy()
+	got.MergeFrom(overlay)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeFrom() =\n%#v\nwant\n%#v", got, want)
+	}
+	if !reflect.DeepEqual(base, newBase()) {
+		t.Error("MergeFrom() modified the copied value")
+	}
+	if !reflect.DeepEqual(overlay, newOverlay()) {
+		t.Error("MergeFrom() modified the source")
+	}
+	if err := rt.NoAlias(got, overlay); err != nil {
+		t.Errorf("MergeFrom() aliased the source: %v", err)
+	}
+}
+
+func TestConfigMergeFromZero(t *testing.T) {
+	got := newBase()
+	got.MergeFrom(Config{})
+
+	if !reflect.DeepEqual(got, newBase()) {
+		t.Errorf("MergeFrom() of the zero value =\n%#v\nwant\n%#v", got, newBase())
+	}
+}
+
+func TestConfigMergeFromNilPointers(t *testing.T) {
+	var got Config
+	overlay := newOverlay()
+	got.MergeFrom(overlay)
+
+	if !reflect.DeepEqual(got.Backup, overlay.Backup) {
+		t.Errorf("MergeFrom() Backup = %#v, want %#v", got.Backup, overlay.Backup)
+	}
+	if got.Limits == nil || got.Env == nil {
+		t.Error("MergeFrom() left the maps nil")
+	}
+	if err := rt.NoAlias(got, overlay); err != nil {
+		t.Errorf("MergeFrom() aliased the source: %v", err)
+	}
+}
+
+func TestConfigMergeFromSkipped(t *testing.T) {
+	overlay := Config{
+		Proxy:   &Proxy{URL: "http://proxy", Headers: map[string]string{"A": "1"}, Cache: []byte("proxy")},
+		Mirrors: []Proxy{{URL: "http://mirror", Headers: map[string]string{"B": "2"}, Cache: []byte("mirror")}},
+	}
+	got := newBase()
+	got.MergeFrom(overlay)
+
+	if !reflect.DeepEqual(got.Proxy, overlay.Proxy) || !reflect.DeepEqual(got.Mirrors, overlay.Mirrors) {
+		t.Errorf("MergeFrom() Proxy, Mirrors = %#v, %#v, want %#v, %#v", got.Proxy, got.Mirrors, overlay.Proxy, overlay.Mirrors)
+	}
+
+	// The replaced members are deep copied but for the skipped ones, which
+	// are shared as in deep copies.
+	if &got.Proxy.Cache[0] != &overlay.Proxy.Cache[0] || &got.Mirrors[0].Cache[0] != &overlay.Mirrors[0].Cache[0] {
+		t.Error("MergeFrom() copied the skipped caches")
+	}
+	if err := rt.NoAlias(got, overlay, rt.WithSkip("Proxy.Cache", "Mirrors[i].Cache")); err != nil {
+		t.Errorf("MergeFrom() aliased the source: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globusdigital/deep-copy/deepcopy"
+	"golang.org/x/tools/go/packages"
+)
+
+// multiplePackages reports whether the package arguments may match several
+// packages, whose output files are then written in the directory of each
+// package.
+func multiplePackages(args []string) bool {
+	return len(args) > 1 || slices.ContainsFunc(args, func(arg string) bool {
+		return strings.Contains(arg, "...")
+	})
+}
+
+// mainPackages generates for the several packages given on the command line,
+// writing the output file named by -o in the directory of each package. The
+// diagnostics are reported in the order of the packages, followed by a
+// summary. The manifest is written when diagnostics are recorded for it.
+func mainPackages(g deepcopy.Generator, cfg deepcopy.LoadConfig, tm *timings, diagnostics *diagnosticsLog) {
+	if outputF.file == nil {
+		log.Fatalln("Several packages require an output file, written in the directory of each package")
+	}
+
+	output := outputF.name
+	if filepath.Base(output) != output {
+		log.Fatalln("Several packages require an output file name, without a directory:", output)
+	}
+	outputF.Discard()
+
+	if *cacheDirF != "" {
+		log.Println("WARNING: --cache-dir only applies to a single package, ignoring it")
+	}
+
+	results, err := runPackages(g, flag.Args(), typesF, cfg, output, *emitTestsF, *jobsF, tm)
+	if err != nil {
+		log.Fatalln("Error generating deep copy methods:", err)
+	}
+
+	var inputs map[string][]string
+	if diagnostics != nil {
+		if inputs, err = inputFiles(cfg, flag.Args()...); err != nil {
+			log.Fatalln("Error listing the inputs of the manifest:", err)
+		}
+	}
+
+	m, failed := reportResults(results, *emitTestsF, inputs, diagnostics)
+
+	if tm != nil {
+		log.Printf("timing: %v", *tm)
+	}
+	log.Printf("%d packages generated, %d failed", len(results)-failed, failed)
+
+	if diagnostics != nil {
+		if err := m.write(*manifestF, diagnostics); err != nil {
+			log.Fatalln("Error writing manifest:", err)
+		}
+	}
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// reportResults writes the output files of the packages generated, logging
+// the warnings and errors of each package in order. It returns the number of
+// packages that failed, along with the manifest of the generated files when
+// diagnostics are recorded for it, which then records the errors as well.
+func reportResults(
+	results []packageResult, emitTests bool, inputs map[string][]string, diagnostics *diagnosticsLog,
+) (manifest, int) {
+	var (
+		m      manifest
+		failed int
+	)
+	for _, r := range results {
+		for _, w := range r.gen.warnings {
+			log.Printf("WARNING: package %s: %s", r.path, w)
+		}
+		for _, w := range r.warnings {
+			log.Printf("WARNING: package %s: %v", r.path, w)
+		}
+
+		if r.err == nil {
+			r.err = writeResult(r, emitTests)
+		}
+		if r.err != nil {
+			failed++
+			msg := fmt.Sprintf("Error generating deep copy methods of %s: %v", r.path, r.err)
+			log.Print(msg)
+			if diagnostics != nil {
+				diagnostics.add(msg)
+			}
+			continue
+		}
+
+		if diagnostics != nil {
+			m.add(r.output, r.path, r.code, r.gen.types, inputs[r.path])
+			if emitTests {
+				m.add(testOutputPath(r.output), r.path, r.tests, r.gen.types, inputs[r.path])
+			}
+		}
+	}
+
+	return m, failed
+}
+
+// writeResult writes the output files of the package.
+func writeResult(r packageResult, emitTests bool) error {
+	if err := os.WriteFile(r.output, r.code, 0o666); err != nil {
+		return err
+	}
+
+	if emitTests {
+		return os.WriteFile(testOutputPath(r.output), r.tests, 0o666)
+	}
+
+	return nil
+}
+
+// packageResult is the outcome of generating for one of several packages.
+type packageResult struct {
+	// path is the import path of the package, and output the path of its
+	// output file.
+	path   string
+	output string
+
+	code, tests []byte
+
+	// gen describes what was generated, and warnings are the errors of the
+	// package that did not prevent generating it.
+	gen      generated
+	warnings []packages.Error
+	err      error
+}
+
+// target is a package to generate for, or the error of finding it.
+type target struct {
+	path string
+	p    *packages.Package
+	err  error
+}
+
+// runPackages generates the deep copy methods of the types of every package
+// matching the patterns into a file named output, in the directory of each
+// package, along with their tests if emitTests is set. The packages are loaded
+// once, then generated on at most jobs workers. The results are sorted by
+// import path, whatever order the workers complete them in.
+func runPackages(
+	g deepcopy.Generator, patterns []string, types typesVal, cfg deepcopy.LoadConfig,
+	output string, emitTests bool, jobs int, tm *timings,
+) ([]packageResult, error) {
+	start := time.Now()
+	dirs, err := deepcopy.Dirs(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("listing packages: %v", err)
+	}
+
+	// The previous outputs are left out of the packages, as they may no
+	// longer compile with the types they were generated from.
+	cfg.Exclude = slices.Clone(cfg.Exclude)
+	for _, dir := range dirs {
+		cfg.Exclude = append(cfg.Exclude, filepath.Join(dir, output))
+		if emitTests {
+			cfg.Exclude = append(cfg.Exclude, filepath.Join(dir, testOutputPath(output)))
+		}
+	}
+
+	pkgs, err := deepcopy.Load(cfg, patterns...)
+	tm.since("load", start)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages: %v", err)
+	}
+
+	targets := findTargets(g, pkgs, types, cfg.Tests)
+	if len(targets) == 0 {
+		return nil, errors.New("no package found")
+	}
+
+	start = time.Now()
+	results := make([]packageResult, len(targets))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range max(1, min(jobs, len(targets))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				results[i] = generatePackage(g, targets[i], types, output, emitTests)
+			}
+		}()
+	}
+	for i := range targets {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	tm.since("generate", start)
+
+	return results, nil
+}
+
+// findTargets returns the packages to generate for among the loaded ones,
+// sorted by import path. With tests, that is the test variant of each package
+// declaring the types.
+func findTargets(g deepcopy.Generator, pkgs []*packages.Package, types typesVal, tests bool) []target {
+	var targets []target
+	for _, p := range pkgs {
+		if !tests {
+			targets = append(targets, target{path: p.PkgPath, p: p})
+			continue
+		}
+
+		// The test variants of a package have IDs like "path [path.test]",
+		// next to the package itself and its test binary "path.test".
+		if p.ID != p.PkgPath || strings.HasSuffix(p.ID, ".test") {
+			continue
+		}
+
+		var variants []*packages.Package
+		for _, v := range pkgs {
+			if strings.HasSuffix(v.ID, " ["+p.PkgPath+".test]") {
+				variants = append(variants, v)
+			}
+		}
+
+		variant, err := g.TestVariant(variants, types)
+		targets = append(targets, target{path: p.PkgPath, p: variant, err: err})
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].path < targets[j].path
+	})
+
+	return targets
+}
+
+// generatePackage generates the output of the target in memory.
+func generatePackage(g deepcopy.Generator, t target, types typesVal, output string, emitTests bool) packageResult {
+	r := packageResult{path: t.path, err: t.err}
+	if r.err != nil {
+		return r
+	}
+
+	if len(t.p.GoFiles) == 0 {
+		r.err = fmt.Errorf("package %s has no Go files to write %s next to", t.path, output)
+		return r
+	}
+	r.output = filepath.Join(filepath.Dir(t.p.GoFiles[0]), output)
+
+	var code, tests bytes.Buffer
+	var tw io.Writer
+	if emitTests {
+		tw = &tests
+	}
+
+	if r.gen, r.err = generate(g, &code, tw, types, t.p); r.err != nil {
+		return r
+	}
+
+	r.code, r.tests = code.Bytes(), tests.Bytes()
+	r.warnings = deepcopy.PackageErrors(t.p)
+
+	return r
+}
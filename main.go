@@ -1,13 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/globusdigital/deep-copy/deepcopy"
 	"golang.org/x/tools/go/packages"
@@ -18,11 +25,39 @@ var (
 	pointerReceiverF = flag.Bool("pointer-receiver", false, "the generated receiver type")
 	maxDepthF        = flag.Int("maxdepth", 0, "max depth of deep copying")
 	methodF          = flag.String("method", "DeepCopy", "deep copy method name")
-
-	typesF     typesVal
-	skipsF     skipsVal
-	outputF    outputVal
-	buildTagsF buildTagsVal
+	markersF         = flag.Bool("markers", false, "select types and interface methods from +k8s:deepcopy-gen markers")
+	emitTestsF       = flag.Bool("emit-tests", false, "also write tests of the generated methods to a _deepcopy_test.go file next to the output file")
+	goVersionF       = flag.String("go-version", "", "minimum Go version of the generated code. Defaults to the go directive of the package's module")
+	allF             = flag.Bool("all", false, "generate every exported named struct type of the package")
+	typePatternF     = flag.String("type-pattern", "", "generate the named struct types whose name matches the regular expression")
+	goosF            = flag.String("goos", "", "the GOOS to load the package for. Defaults to the host one")
+	goarchF          = flag.String("goarch", "", "the GOARCH to load the package for. Defaults to the host one")
+	overlayF         = flag.String("overlay", "", "JSON file replacing files of the package, in the format of go build -overlay")
+	testsF           = flag.Bool("tests", false, "also load the _test.go files of the package, generating for the test variant declaring the types into a _test.go file")
+	exportDataF      = flag.Bool("export-data", false, "type-check only the package, importing its dependencies from export data")
+	cacheDirF        = flag.String("cache-dir", "", "directory caching the output by content hash of the package, to skip regenerating unchanged packages")
+	timingF          = flag.Bool("timing", false, "report the time spent loading the package and generating")
+	equalF           = flag.Bool("equal", false, "also generate an Equal method on every type, comparing what the deep copy method copies")
+	nilEqualsEmptyF  = flag.Bool("equal-nil-empty", false, "consider nil and empty slices and maps equal in the Equal and Diff methods")
+	sizeF            = flag.Bool("size", false, "also generate a DeepSize method on every type, estimating the memory the value and its deep copy hold")
+	mergeF           = flag.Bool("merge", false, "also generate a MergeFrom method on every type, overlaying the non-zero members of a value onto the receiver")
+	diffF            = flag.Bool("diff", false, "also generate a Diff method on every type, reporting the changes of what the deep copy method copies")
+	jobsF            = flag.Int("j", runtime.GOMAXPROCS(0), "the number of packages to generate concurrently")
+	jsonF            = flag.Bool("json", false, "print the copy plans of the explain subcommand as JSON")
+	headerFileF      = flag.String("header-file", "", "text/template file of a banner, as in a license, written above the generated code marker. It is executed with the package name, the types and the tool version")
+	manifestF        = flag.String("manifest", "", "JSON file to write the generated files to, with their package, types, options, inputs and hash, and the warnings of the run")
+
+	typesF        typesVal
+	excludeTypesF typesVal
+	skipsF        skipsVal
+	outputF       outputVal
+	buildTagsF    buildTagsVal
+	loadTagsF     buildTagsVal
+	convertsF     convertsVal
+
+	// headerText is the header template given inline by the header entry of
+	// the configuration file, used unless --header-file is given.
+	headerText string
 )
 
 type typesVal []string
@@ -45,6 +80,7 @@ func (f *skipsVal) String() string {
 		for k := range m {
 			keys = append(keys, k)
 		}
+		slices.Sort(keys)
 		parts = append(parts, strings.Join(keys, ","))
 	}
 
@@ -66,6 +102,9 @@ func (f *skipsVal) Set(v string) error {
 type outputVal struct {
 	file *os.File
 	name string
+
+	// created reports whether Set created the file.
+	created bool
 }
 
 func (f *outputVal) String() string {
@@ -84,6 +123,9 @@ func (f *outputVal) Set(v string) error {
 		return nil
 	}
 
+	_, err := os.Stat(v)
+	created := errors.Is(err, fs.ErrNotExist)
+
 	file, err := os.OpenFile(v, os.O_RDWR|os.O_CREATE, 0o666)
 	if err != nil {
 		return fmt.Errorf("opening file: %v", v)
@@ -91,10 +133,25 @@ func (f *outputVal) Set(v string) error {
 
 	f.name = v
 	f.file = file
+	f.created = created
 
 	return nil
 }
 
+// Discard closes the file, removing it if Set created it, when the output is
+// not written to it.
+func (f *outputVal) Discard() {
+	if f.file == nil {
+		return
+	}
+
+	_ = f.file.Close()
+	if f.created {
+		_ = os.Remove(f.name)
+	}
+	f.file = nil
+}
+
 func (f *outputVal) Open() (io.WriteCloser, error) {
 	if f.file == nil {
 		f.file = os.Stdout
@@ -119,66 +176,391 @@ func (b *buildTagsVal) Set(v string) error {
 	return nil
 }
 
+type convertsVal []deepcopy.Conversion
+
+func (c *convertsVal) String() string {
+	parts := make([]string, len(*c))
+	for i, conv := range *c {
+		parts[i] = conv.String()
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (c *convertsVal) Set(v string) error {
+	conv, err := deepcopy.ParseConversion(v)
+	if err != nil {
+		return err
+	}
+
+	*c = append(*c, conv)
+	return nil
+}
+
 func init() {
 	flag.Var(&typesF, "type", "the concrete type. Multiple flags can be specified")
+	flag.Var(&excludeTypesF, "exclude-type", "a type to leave out of --all, --type-pattern and --markers. Multiple flags can be specified")
 	flag.Var(&skipsF, "skip", "comma-separated field/slice/map selectors to shallow copy. Multiple flags can be specified")
 	flag.Var(&outputF, "o", "the output file to write to. Defaults to STDOUT")
 	flag.Var(&buildTagsF, "tags", "comma-separated build tags to add to generated file")
+	flag.Var(&convertsF, "convert", "generate a function converting a struct type to another, as in domain.Order:api.Order, optionally followed by =FuncName. Multiple flags can be specified")
+	flag.Var(&loadTagsF, "load-tags", "comma-separated build tags to load the package with. Multiple flags can be specified")
 }
 
 func main() {
-	flag.Parse()
+	// deep-copy explain takes the same flags, printing the copy plans of the
+	// types instead of generating them.
+	args := os.Args[1:]
+	explaining := len(args) > 0 && args[0] == "explain"
+	if explaining {
+		args = args[1:]
+	}
+	_ = flag.CommandLine.Parse(args)
 
 	if err := loadConfig(); err != nil {
 		log.Fatalf("Error loading configuration: %v", err)
 	}
 
-	if len(typesF) == 0 || typesF[0] == "" {
+	var diagnostics *diagnosticsLog
+	if *manifestF != "" {
+		diagnostics = recordDiagnostics()
+	}
+
+	if !*markersF && !*allF && *typePatternF == "" && len(convertsF) == 0 && (len(typesF) == 0 || typesF[0] == "") {
 		log.Fatalln("no type given")
 	}
 
-	if flag.NArg() != 1 {
+	var typePattern *regexp.Regexp
+	if *typePatternF != "" {
+		var err error
+		typePattern, err = regexp.Compile(*typePatternF)
+		if err != nil {
+			log.Fatalln("Invalid type pattern:", err)
+		}
+	}
+
+	if flag.NArg() == 0 {
 		log.Fatalln("No package path given")
 	}
 
+	cfg := deepcopy.LoadConfig{
+		Tests:      *testsF,
+		BuildTags:  loadTagsF,
+		GOOS:       *goosF,
+		GOARCH:     *goarchF,
+		ExportData: *exportDataF,
+	}
+	if *overlayF != "" {
+		var err error
+		cfg.Overlay, err = deepcopy.ReadOverlay(*overlayF)
+		if err != nil {
+			log.Fatalln("Error reading overlay:", err)
+		}
+	}
+
+	header, err := headerTemplate()
+	if err != nil {
+		log.Fatalln("Error reading header template:", err)
+	}
+
 	sl := deepcopy.SkipLists(skipsF)
 	generator := deepcopy.NewGenerator(
 		deepcopy.IsPtrRecv(*pointerReceiverF),
 		deepcopy.WithMethodName(*methodF),
 		deepcopy.WithSkipLists(sl),
 		deepcopy.WithMaxDepth(*maxDepthF),
-		deepcopy.WithBuildTags(buildTagsF),
+		deepcopy.WithBuildTags(outputTags(buildTagsF, cfg)),
+		deepcopy.WithMarkers(*markersF),
+		deepcopy.WithGoVersion(*goVersionF),
+		deepcopy.WithAllTypes(*allF),
+		deepcopy.WithTypePattern(typePattern),
+		deepcopy.WithExcludedTypes(excludeTypesF),
+		deepcopy.WithEqual(*equalF),
+		deepcopy.WithNilEqualsEmpty(*nilEqualsEmptyF),
+		deepcopy.WithDiff(*diffF),
+		deepcopy.WithSize(*sizeF),
+		deepcopy.WithMerge(*mergeF),
+		deepcopy.WithConversions(convertsF),
+		deepcopy.WithHeader(header),
 	)
 
+	if explaining {
+		outputF.Discard()
+		if err := explain(generator, os.Stdout, flag.Arg(0), typesF, cfg, *jsonF); err != nil {
+			log.Fatalln("Error explaining the copy plan:", err)
+		}
+		return
+	}
+
+	if *testsF && outputF.file != nil && !strings.HasSuffix(outputF.name, "_test.go") {
+		log.Fatalln("--tests requires a _test.go output file")
+	}
+
+	if *emitTestsF && outputF.file == nil {
+		log.Fatalln("--emit-tests requires an output file")
+	}
+
+	var tm *timings
+	if *timingF {
+		tm = &timings{}
+	}
+
+	if multiplePackages(flag.Args()) {
+		mainPackages(generator, cfg, tm, diagnostics)
+		return
+	}
+
+	if outputF.file != nil {
+		// The previous output is left out of the package, as it may no
+		// longer compile with the types it was generated from.
+		cfg.Exclude = append(cfg.Exclude, outputF.name)
+		if *emitTestsF {
+			cfg.Exclude = append(cfg.Exclude, testOutputPath(outputF.name))
+		}
+	}
+
+	var (
+		cache    outputCache
+		cacheKey string
+		out      cachedOutput
+		cached   bool
+	)
+	if *cacheDirF != "" {
+		cache = outputCache{dir: *cacheDirF}
+
+		start := time.Now()
+		var err error
+		cacheKey, out, cached, err = cache.lookup(cfg, flag.Args()[0])
+		if err != nil {
+			log.Println("WARNING: not caching the output:", err)
+		}
+		tm.since("fingerprint", start)
+	}
+
+	if !cached {
+		// The output is generated in memory, so that nothing is written if
+		// generating or type-checking fails.
+		var code, tests bytes.Buffer
+		var tw io.Writer
+		if *emitTestsF {
+			tw = &tests
+		}
+
+		gen, err := run(generator, &code, tw, flag.Args()[0], typesF, cfg, tm)
+		if err != nil {
+			log.Fatalln("Error generating deep copy method:", err)
+		}
+
+		out = cachedOutput{Code: code.Bytes(), Tests: tests.Bytes(), Package: gen.pkg, Types: gen.types}
+		if cacheKey != "" {
+			if err := cache.put(cacheKey, out); err != nil {
+				log.Println("WARNING: caching the output:", err)
+			}
+		}
+	}
+
+	if tm != nil {
+		if cached {
+			log.Printf("timing: %v, output cached", *tm)
+		} else {
+			log.Printf("timing: %v", *tm)
+		}
+	}
+
+	outputPath := outputF.name
+	if outputF.file == nil {
+		outputPath = "-"
+	}
+
 	output, err := outputF.Open()
 	if err != nil {
 		log.Fatalln("Error initializing output file:", err)
 	}
+	if _, err := output.Write(out.Code); err != nil {
+		log.Fatalln("Error writing output file:", err)
+	}
+	output.Close()
 
-	err = run(generator, output, flag.Args()[0], typesF)
-	if err != nil {
-		log.Fatalln("Error generating deep copy method:", err)
+	if *emitTestsF {
+		if err := os.WriteFile(testOutputPath(outputF.name), out.Tests, 0o666); err != nil {
+			log.Fatalln("Error writing test output file:", err)
+		}
 	}
 
-	output.Close()
+	if diagnostics != nil {
+		inputs, err := inputFiles(cfg, flag.Args()...)
+		if err != nil {
+			log.Fatalln("Error listing the inputs of the manifest:", err)
+		}
+
+		var m manifest
+		m.add(outputPath, out.Package, out.Code, out.Types, inputs[out.Package])
+		if *emitTestsF {
+			m.add(testOutputPath(outputPath), out.Package, out.Tests, out.Types, inputs[out.Package])
+		}
+		if err := m.write(*manifestF, diagnostics); err != nil {
+			log.Fatalln("Error writing manifest:", err)
+		}
+	}
+}
+
+// testOutputPath returns the path of the test file emitted next to the
+// generated file at path.
+func testOutputPath(path string) string {
+	base := strings.TrimSuffix(path, ".go")
+	if !strings.HasSuffix(base, "_deepcopy") {
+		base += "_deepcopy"
+	}
+
+	return base + "_test.go"
 }
 
+// outputTags returns the build constraints of the generated file: the given
+// ones, or else the one the package was loaded with, so that the generated
+// file is built along with the files it was generated from.
+func outputTags(tags []string, cfg deepcopy.LoadConfig) []string {
+	if len(tags) > 0 {
+		return tags
+	}
+
+	var terms []string
+	for _, t := range []string{cfg.GOOS, cfg.GOARCH} {
+		if t != "" {
+			terms = append(terms, t)
+		}
+	}
+	for _, t := range cfg.BuildTags {
+		for _, tag := range strings.Split(t, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				terms = append(terms, tag)
+			}
+		}
+	}
+
+	if len(terms) == 0 {
+		return nil
+	}
+
+	return []string{strings.Join(terms, " && ")}
+}
+
+// headerTemplate returns the template of the header of the generated files,
+// read from --header-file or else given inline by the configuration file, if
+// any.
+func headerTemplate() (*template.Template, error) {
+	text := headerText
+	if *headerFileF != "" {
+		b, err := os.ReadFile(*headerFileF)
+		if err != nil {
+			return nil, err
+		}
+		text = string(b)
+	}
+
+	if text == "" {
+		return nil, nil
+	}
+
+	return template.New("header").Parse(text)
+}
+
+// run generates the deep copy methods of the types of the package at path
+// into w, and their tests into tw if it is not nil, returning what it
+// generated. The time spent in each phase is recorded in tm if it is not nil.
 func run(
-	g deepcopy.Generator, w io.Writer, path string, types typesVal,
-) error {
-	packages, err := load(path)
+	g deepcopy.Generator, w, tw io.Writer, path string, types typesVal, cfg deepcopy.LoadConfig, tm *timings,
+) (generated, error) {
+	start := time.Now()
+	pkgs, err := deepcopy.Load(cfg, path)
+	tm.since("load", start)
 	if err != nil {
-		return fmt.Errorf("loading package: %v", err)
+		return generated{}, fmt.Errorf("loading package: %v", err)
 	}
-	if len(packages) == 0 {
-		return errors.New("no package found")
+	if len(pkgs) == 0 {
+		return generated{}, errors.New("no package found")
 	}
 
-	return g.Generate(w, types, packages[0])
+	p := pkgs[0]
+	if cfg.Tests {
+		p, err = g.TestVariant(pkgs, types)
+		if err != nil {
+			return generated{}, err
+		}
+	}
+
+	start = time.Now()
+	gen, err := generate(g, w, tw, types, p)
+	tm.since("generate", start)
+	if err != nil {
+		return generated{}, err
+	}
+
+	for _, w := range gen.warnings {
+		log.Printf("WARNING: %s", w)
+	}
+
+	// The errors did not prevent generating the requested types, yet the
+	// package won't build until they are fixed.
+	for _, e := range deepcopy.PackageErrors(p) {
+		log.Printf("WARNING: package %s: %v", p.PkgPath, e)
+	}
+
+	return gen, nil
+}
+
+// generated describes the output generated for a package.
+type generated struct {
+	// pkg is the import path of the package, and types the names of the
+	// types whose methods were generated, sorted.
+	pkg   string
+	types []string
+	// warnings are the warnings of generating the methods.
+	warnings []string
 }
 
-func load(patterns string) ([]*packages.Package, error) {
-	return packages.Load(&packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedImports,
-	}, patterns)
+// generate generates the deep copy methods of the types of the loaded package
+// into w, and their tests into tw if it is not nil.
+func generate(g deepcopy.Generator, w, tw io.Writer, types typesVal, p *packages.Package) (generated, error) {
+	gen := generated{pkg: p.PkgPath}
+
+	var err error
+	if gen.warnings, err = g.GenerateWithWarnings(w, types, p); err != nil {
+		return generated{}, err
+	}
+	if gen.types, err = g.GeneratedTypes(types, p); err != nil {
+		return generated{}, err
+	}
+	slices.Sort(gen.types)
+
+	if tw != nil {
+		return gen, g.GenerateTests(tw, types, p)
+	}
+
+	return gen, nil
+}
+
+// timings records the duration of the phases of a run, for the --timing
+// report.
+type timings []timing
+
+type timing struct {
+	phase    string
+	duration time.Duration
+}
+
+// since records the phase as having started at start. It does nothing on a
+// nil receiver, so that untimed runs pass nil.
+func (t *timings) since(phase string, start time.Time) {
+	if t != nil {
+		*t = append(*t, timing{phase: phase, duration: time.Since(start)})
+	}
+}
+
+func (t timings) String() string {
+	parts := make([]string, len(t))
+	for i, tm := range t {
+		parts[i] = fmt.Sprintf("%s %v", tm.phase, tm.duration.Round(time.Millisecond))
+	}
+
+	return strings.Join(parts, ", ")
 }
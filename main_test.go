@@ -2,11 +2,22 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/globusdigital/deep-copy/deepcopy"
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/tools/go/packages"
 )
 
 func Test_run(t *testing.T) {
@@ -19,6 +30,16 @@ func Test_run(t *testing.T) {
 		maxdepth  int
 		buildTags []string
 		method    string
+		markers   bool
+		goVersion string
+		all       bool
+		pattern   string
+		exclude   typesVal
+		equal     bool
+		nilEmpty  bool
+		diff      bool
+		size      bool
+		load      deepcopy.LoadConfig
 		want      []byte
 	}{
 		{name: "foo", types: typesVal{"Foo"}, path: "./testdata", want: []byte(FooFile)},
@@ -45,6 +66,29 @@ func Test_run(t *testing.T) {
 		{name: "issue 17, with maxdepth", types: typesVal{"Depth1"}, pointer: true, maxdepth: 2, path: "./testdata", want: []byte(Issue17MaxDepth)},
 		{name: "alias import", types: typesVal{"Data"}, path: "./testdata/import_alias", want: []byte(AliasImport)},
 		{name: "using build tags", types: typesVal{"Foo"}, path: "./testdata", buildTags: []string{"!myTag", "anotherOne"}, want: []byte(FooFileBuildTags)},
+		{name: "k8s markers", markers: true, path: "./testdata/k8s/apis/v1", want: []byte(K8sMarkers)},
+		{name: "locals shadowing package names", types: typesVal{"Shadowed"}, path: "./testdata/shadow", want: []byte(ShadowedLocals)},
+		{name: "fields declared through aliases", types: typesVal{"Config"}, pointer: true, path: "./testdata/aliases", want: []byte(AliasedFields)},
+		{name: "alias of a local type", types: typesVal{"Widget"}, pointer: true, path: "./testdata/aliases", want: []byte(AliasedFields)},
+		{name: "generic aliases", types: typesVal{"Index"}, path: "./testdata/aliases", want: []byte(GenericAliases)},
+		{name: "foo, go 1.21 clones", types: typesVal{"Foo"}, path: "./testdata", goVersion: "1.21", want: []byte(FooCloneSlicesFile)},
+		{name: "issue 12, nested slices, go 1.21 clones", types: typesVal{"I12NestedSlices"}, path: "./testdata", goVersion: "go1.21.0", want: []byte(Issue12NestedSlicesClone)},
+		{name: "issue 3, struct with map of simple struct values, go 1.21 clones", types: typesVal{"I3WithMapVal"}, path: "./testdata", goVersion: "1.22", want: []byte(Issue3MapSimpleStructValClone)},
+		{name: "all types", all: true, path: "./testdata/selection", want: []byte(SelectionAll)},
+		{name: "type pattern, excluded type", pattern: "Req$|Resp$", exclude: typesVal{"CreateResp"}, path: "./testdata/selection", want: []byte(SelectionPattern)},
+		{name: "type pattern and explicit type", types: typesVal{"Server"}, pattern: "^Create", exclude: typesVal{"CreateResp", "Server"}, path: "./testdata/selection", want: []byte(SelectionPatternExplicit)},
+		{name: "qualified type", types: typesVal{"github.com/globusdigital/deep-copy/testdata/lookup.Order"}, path: "./testdata/lookup", want: []byte(QualifiedType)},
+		{name: "pointer that implements DeepCopy, export data", types: typesVal{"SomeStruct"}, path: "./testdata/pointer_that_implements_deepcopy/somepkg", load: deepcopy.LoadConfig{ExportData: true}, want: []byte(PointerThatImplementsDeepcopy)},
+		{name: "qualified type, export data", types: typesVal{"github.com/globusdigital/deep-copy/testdata/lookup.Order"}, path: "./testdata/lookup", load: deepcopy.LoadConfig{ExportData: true}, want: []byte(QualifiedType)},
+		{name: "tests, internal test variant", types: typesVal{"fixture"}, load: deepcopy.LoadConfig{Tests: true}, path: "./testdata/testvariants", want: []byte(TestsInternalVariant)},
+		{name: "tests, internal test variant, export data", types: typesVal{"fixture"}, path: "./testdata/testvariants", load: deepcopy.LoadConfig{Tests: true, ExportData: true}, want: []byte(TestsInternalVariant)},
+		{name: "tests, external test package", types: typesVal{"Case"}, load: deepcopy.LoadConfig{Tests: true}, path: "./testdata/testvariants", want: []byte(TestsExternalPackage)},
+		{name: "load tags and platform", types: typesVal{"License"}, load: deepcopy.LoadConfig{BuildTags: []string{"enterprise"}, GOOS: "linux"}, path: "./testdata/platform", want: []byte(PlatformLicense)},
+		{name: "load platform, output tags", types: typesVal{"Plan9"}, load: deepcopy.LoadConfig{GOOS: "plan9", GOARCH: "amd64"}, buildTags: []string{"plan9"}, path: "./testdata/platform", want: []byte(PlatformPlan9)},
+		{name: "foo - pointer, equal, nil equals empty", types: typesVal{"Foo"}, pointer: true, equal: true, nilEmpty: true, path: "./testdata", want: []byte(FooPointerEqualNilEmpty)},
+		{name: "foo - pointer, diff, nil equals empty", types: typesVal{"Foo"}, pointer: true, diff: true, nilEmpty: true, path: "./testdata", want: []byte(FooPointerDiffNilEmpty)},
+		{name: "foo - pointer, size", types: typesVal{"Foo"}, pointer: true, size: true, path: "./testdata", want: []byte(FooPointerSize)},
+		{name: "clone packages shadowed by package names", types: typesVal{"ShadowedImports"}, path: "./testdata/shadow", goVersion: "1.21", want: []byte(ShadowedImports)},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -52,15 +96,33 @@ func Test_run(t *testing.T) {
 			if tt.method != "" {
 				method = tt.method
 			}
+			// Most expected outputs predate slices.Clone and maps.Clone.
+			goVersion := "1.20"
+			if tt.goVersion != "" {
+				goVersion = tt.goVersion
+			}
+			var pattern *regexp.Regexp
+			if tt.pattern != "" {
+				pattern = regexp.MustCompile(tt.pattern)
+			}
 			g := deepcopy.NewGenerator(
 				deepcopy.IsPtrRecv(tt.pointer),
 				deepcopy.WithMethodName(method),
 				deepcopy.WithSkipLists(deepcopy.SkipLists(tt.skips)),
 				deepcopy.WithMaxDepth(tt.maxdepth),
-				deepcopy.WithBuildTags(tt.buildTags),
+				deepcopy.WithBuildTags(outputTags(tt.buildTags, tt.load)),
+				deepcopy.WithMarkers(tt.markers),
+				deepcopy.WithGoVersion(goVersion),
+				deepcopy.WithAllTypes(tt.all),
+				deepcopy.WithTypePattern(pattern),
+				deepcopy.WithExcludedTypes(tt.exclude),
+				deepcopy.WithEqual(tt.equal),
+				deepcopy.WithNilEqualsEmpty(tt.nilEmpty),
+				deepcopy.WithDiff(tt.diff),
+				deepcopy.WithSize(tt.size),
 			)
 			var buf bytes.Buffer
-			err := run(g, &buf, tt.path, tt.types)
+			_, err := run(g, &buf, nil, tt.path, tt.types, tt.load, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -72,6 +134,520 @@ func Test_run(t *testing.T) {
 	}
 }
 
+func Test_runErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		types     typesVal
+		path      string
+		goVersion string
+		load      deepcopy.LoadConfig
+		wantErr   string
+	}{
+		{name: "alias of a type of another package", types: typesVal{"Item"}, path: "./testdata/aliases", wantErr: `locating type "Item" in "aliases": Item is an alias of github.com/globusdigital/deep-copy/testdata/aliases/other.Item, which is declared in another package`},
+		{name: "alias of an unnamed type", types: typesVal{"Refs"}, path: "./testdata/aliases", wantErr: `locating type "Refs" in "aliases": Refs is an alias of map[string]*Ref, which is not a named type`},
+		{name: "generic alias", types: typesVal{"Set"}, path: "./testdata/aliases", wantErr: `locating type "Set" in "aliases": Set is an alias of map[T]struct{}, which is not a named type`},
+		{name: "typo", types: typesVal{"OrderItm"}, path: "./testdata/lookup", wantErr: "locating type \"OrderItm\" in \"lookup\": type not found, did you mean `OrderItem`?"},
+		{name: "several suggestions", types: typesVal{"orderitem2"}, path: "./testdata/lookup", wantErr: "locating type \"orderitem2\" in \"lookup\": type not found, did you mean `OrderItem` or `OrderItems`?"},
+		{name: "local type of a function", types: typesVal{"Local"}, path: "./testdata/lookup", wantErr: `locating type "Local" in "lookup": type not found`},
+		{name: "variable", types: typesVal{"DefaultOrder"}, path: "./testdata/lookup", wantErr: `locating type "DefaultOrder" in "lookup": type not found: DefaultOrder is a variable, not a type`},
+		{name: "function", types: typesVal{"NewOrder"}, path: "./testdata/lookup", wantErr: `locating type "NewOrder" in "lookup": type not found: NewOrder is a function, not a type`},
+		{name: "interface", types: typesVal{"Shape"}, path: "./testdata/lookup", wantErr: `locating type "Shape" in "lookup": Shape is an interface type, not a concrete type`},
+		{name: "qualified type of another package", types: typesVal{"github.com/globusdigital/deep-copy/testdata/aliases/other.Item"}, path: "./testdata/lookup", wantErr: `locating type "github.com/globusdigital/deep-copy/testdata/aliases/other.Item" in "lookup": github.com/globusdigital/deep-copy/testdata/aliases/other.Item is qualified by another package than github.com/globusdigital/deep-copy/testdata/lookup, whose types can't have methods declared in it`},
+		{name: "qualified type of an unknown package", types: typesVal{"example.com/other.Item"}, path: "./testdata/lookup", wantErr: `locating type "example.com/other.Item" in "lookup": example.com/other.Item is qualified by another package than github.com/globusdigital/deep-copy/testdata/lookup, whose types can't have methods declared in it`},
+		{name: "test type without tests", types: typesVal{"fixture"}, path: "./testdata/testvariants", wantErr: `locating type "fixture" in "orders": type not found`},
+		{name: "tests without test files", types: typesVal{"Foo"}, load: deepcopy.LoadConfig{Tests: true}, path: "./testdata/shadow", wantErr: `no test files found`},
+		{name: "type of a file excluded by build tags", types: typesVal{"License"}, path: "./testdata/platform", wantErr: `locating type "License" in "platform": type not found`},
+		{name: "invalid go version", types: typesVal{"Foo"}, path: "./testdata", goVersion: "1.x", wantErr: `invalid Go version "1.x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := run(deepcopy.NewGenerator(deepcopy.WithGoVersion(tt.goVersion)), &buf, nil, tt.path, tt.types, tt.load, nil)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("run() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_runOverlay(t *testing.T) {
+	overlay, err := deepcopy.ReadOverlay("testdata/overlay/overlay.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	g := deepcopy.NewGenerator(deepcopy.WithGoVersion("1.20"))
+	_, err = run(g, &buf, nil, "./testdata/overlay", typesVal{"Draft"}, deepcopy.LoadConfig{Overlay: overlay}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(normalizeComment(buf.Bytes()), []byte(OverlayDraft)); diff != "" {
+		t.Errorf("generateFile() diff = %s", diff)
+	}
+}
+
+func Test_runBrokenPackage(t *testing.T) {
+	stale, err := filepath.Abs("testdata/broken/broken_deepcopy.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		types   typesVal
+		load    deepcopy.LoadConfig
+		wantErr []string
+	}{
+		{name: "previous output excluded", types: typesVal{"Good"}, load: deepcopy.LoadConfig{Exclude: []string{stale}}},
+		{
+			name:  "previous output loaded",
+			types: typesVal{"Removed"},
+			wantErr: []string{
+				`locating type "Removed" in "broken": type not found`,
+				"package github.com/globusdigital/deep-copy/testdata/broken has errors:",
+				"broken_deepcopy.go:6:9: undefined: Removed",
+			},
+		},
+		{
+			name:  "invalid field",
+			types: typesVal{"Bad"},
+			load:  deepcopy.LoadConfig{Exclude: []string{stale}},
+			wantErr: []string{
+				"type Bad: field Items[i] has an invalid type",
+				"broken.go:13:10: undefined: Missing",
+				`broken.go:17:9: cannot use "unrelated"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			g := deepcopy.NewGenerator(deepcopy.WithGoVersion("1.20"))
+			_, err := run(g, &buf, nil, "./testdata/broken", tt.types, tt.load, nil)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(normalizeComment(buf.Bytes()), []byte(BrokenGood)); diff != "" {
+					t.Errorf("generateFile() diff = %s", diff)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("run() error = nil")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("run() error = %v, want it to contain %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func Test_runPackages(t *testing.T) {
+	g := deepcopy.NewGenerator(deepcopy.WithGoVersion("1.20"), deepcopy.WithAllTypes(true))
+	patterns := []string{"./testdata/selection", "./testdata/broken", "./testdata/lookup"}
+
+	results, err := runPackages(g, patterns, nil, deepcopy.LoadConfig{}, "broken_deepcopy.go", false, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, r := range results {
+		paths = append(paths, r.path)
+	}
+	wantPaths := []string{
+		"github.com/globusdigital/deep-copy/testdata/broken",
+		"github.com/globusdigital/deep-copy/testdata/lookup",
+		"github.com/globusdigital/deep-copy/testdata/selection",
+	}
+	if diff := cmp.Diff(paths, wantPaths); diff != "" {
+		t.Fatalf("runPackages() paths diff = %s", diff)
+	}
+
+	// The stale output of the broken package is excluded, yet one of its
+	// types can't be generated.
+	if err := results[0].err; err == nil || !strings.Contains(err.Error(), "type Bad: field Items[i] has an invalid type") {
+		t.Errorf("broken package error = %v", err)
+	}
+	if err := results[1].err; err != nil {
+		t.Errorf("lookup package error = %v", err)
+	}
+	if err := results[2].err; err != nil {
+		t.Fatalf("selection package error = %v", err)
+	}
+
+	if want := filepath.Join("testdata", "selection", "broken_deepcopy.go"); !strings.HasSuffix(results[2].output, want) {
+		t.Errorf("selection output = %s, want it in %s", results[2].output, want)
+	}
+	if diff := cmp.Diff(normalizeComment(results[2].code), []byte(SelectionAll)); diff != "" {
+		t.Errorf("selection code diff = %s", diff)
+	}
+}
+
+func Test_generatePackageWithoutFiles(t *testing.T) {
+	g := deepcopy.NewGenerator(deepcopy.WithAllTypes(true))
+	r := generatePackage(g, target{path: "example.com/empty", p: &packages.Package{PkgPath: "example.com/empty"}}, nil, "empty_deepcopy.go", false)
+	if r.err == nil || r.err.Error() != "package example.com/empty has no Go files to write empty_deepcopy.go next to" {
+		t.Errorf("generatePackage() error = %v", r.err)
+	}
+}
+
+func Test_multiplePackages(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{args: []string{"./pkg"}, want: false},
+		{args: []string{"./pkg", "./other"}, want: true},
+		{args: []string{"./..."}, want: true},
+	}
+	for _, tt := range tests {
+		if got := multiplePackages(tt.args); got != tt.want {
+			t.Errorf("multiplePackages(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func Test_runEmitTests(t *testing.T) {
+	g := deepcopy.NewGenerator(
+		deepcopy.WithSkipLists(deepcopy.SkipLists{{"Notes": struct{}{}}}),
+		deepcopy.WithSize(true),
+	)
+
+	var buf, tests bytes.Buffer
+	_, err := run(g, &buf, &tests, "./internal/fixtures/order", typesVal{"Order", "Customer"}, deepcopy.LoadConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for file, got := range map[string][]byte{
+		"order_deepcopy.go":      buf.Bytes(),
+		"order_deepcopy_test.go": tests.Bytes(),
+	} {
+		want, err := os.ReadFile(filepath.Join("internal/fixtures/order", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := cmp.Diff(normalizeComment(got), normalizeComment(want)); diff != "" {
+			t.Errorf("%s is stale, run go generate: diff = %s", file, diff)
+		}
+	}
+}
+
+func Test_runEqualDiff(t *testing.T) {
+	g := deepcopy.NewGenerator(
+		deepcopy.WithSkipLists(deepcopy.SkipLists{{"Cache": struct{}{}}}),
+		deepcopy.WithEqual(true),
+		deepcopy.WithDiff(true),
+	)
+
+	var buf bytes.Buffer
+	_, err := run(g, &buf, nil, "./internal/fixtures/ledger", typesVal{"Account", "Entry"}, deepcopy.LoadConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("internal/fixtures/ledger/ledger_deepcopy.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(normalizeComment(buf.Bytes()), normalizeComment(want)); diff != "" {
+		t.Errorf("ledger_deepcopy.go is stale, run go generate: diff = %s", diff)
+	}
+}
+
+func Test_runMerge(t *testing.T) {
+	g := deepcopy.NewGenerator(
+		deepcopy.WithSkipLists(deepcopy.SkipLists{{"Revision": struct{}{}, "Proxy.Cache": struct{}{}, "Mirrors[i].Cache": struct{}{}}}),
+		deepcopy.WithMerge(true),
+	)
+
+	var buf bytes.Buffer
+	_, err := run(g, &buf, nil, "./internal/fixtures/settings", typesVal{"Config", "Server"}, deepcopy.LoadConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("internal/fixtures/settings/settings_deepcopy.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(normalizeComment(buf.Bytes()), normalizeComment(want)); diff != "" {
+		t.Errorf("settings_deepcopy.go is stale, run go generate: diff = %s", diff)
+	}
+}
+
+func Test_runConvert(t *testing.T) {
+	g := deepcopy.NewGenerator(deepcopy.WithConversions([]deepcopy.Conversion{
+		{From: "domain.Order", To: "api.Order"},
+		{From: "domain.Customer", To: "api.Customer"},
+	}))
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var buf bytes.Buffer
+	_, err := run(g, &buf, nil, "./internal/fixtures/convert", nil, deepcopy.LoadConfig{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := os.ReadFile("internal/fixtures/convert/convert_deepcopy.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(normalizeComment(buf.Bytes()), normalizeComment(want)); diff != "" {
+		t.Errorf("convert_deepcopy.go is stale, run go generate: diff = %s", diff)
+	}
+
+	for _, w := range []string{
+		"WARNING: ConvertOrder: destination field Version has no source",
+		"WARNING: ConvertOrder: source field Notes has no destination",
+	} {
+		if !strings.Contains(logs.String(), w) {
+			t.Errorf("missing warning %q in:\n%s", w, logs.String())
+		}
+	}
+	if strings.Contains(logs.String(), "Link") {
+		t.Errorf("warned about a field left out by its tag:\n%s", logs.String())
+	}
+}
+
+func Test_explain(t *testing.T) {
+	g := deepcopy.NewGenerator(deepcopy.WithSkipLists(deepcopy.SkipLists{{"Notes": struct{}{}}}))
+
+	var text bytes.Buffer
+	if err := explain(g, &text, "./testdata/explain", typesVal{"Plan"}, deepcopy.LoadConfig{}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"Plan: deep\n",
+		"\n  Steps []Step: deep\n    Steps[i] Step: reuse, calls DeepCopy, assigning its result\n",
+		"\n    Index[k] string (key): shallow\n",
+		"\n  Notes []string: skip, skipped by Notes\n",
+		"\n    Level.Next *Level2: deep\n      Level.Next.Values []int: deep\n        Level.Next.Values[i] int: shallow\n",
+	} {
+		if !strings.Contains(text.String(), line) {
+			t.Errorf("explain() text misses %q:\n%s", line, text.String())
+		}
+	}
+
+	var js bytes.Buffer
+	if err := explain(g, &js, "./testdata/explain", typesVal{"Plan"}, deepcopy.LoadConfig{}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []deepcopy.Explanation
+	if err := json.Unmarshal(js.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Type != "Plan" || len(got[0].Members) != 9 {
+		t.Fatalf("explain() JSON = %s", js.String())
+	}
+	if m := got[0].Members[6]; m.Selector != "Notes" || m.Copy != deepcopy.Skipped || m.Reason != "skipped by Notes" {
+		t.Errorf("explain() JSON Notes = %+v", m)
+	}
+}
+
+func Test_manifest(t *testing.T) {
+	stale, err := filepath.Abs("testdata/broken/broken_deepcopy.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := deepcopy.LoadConfig{Exclude: []string{stale}}
+
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	diagnostics := recordDiagnostics()
+
+	var code bytes.Buffer
+	gen, err := run(deepcopy.NewGenerator(), &code, nil, "./testdata/broken", typesVal{"Good"}, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs, err := inputFiles(cfg, "./testdata/broken")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const pkg = "github.com/globusdigital/deep-copy/testdata/broken"
+	if gen.pkg != pkg {
+		t.Errorf("run() package = %s, want %s", gen.pkg, pkg)
+	}
+	var m manifest
+	m.add(stale, gen.pkg, code.Bytes(), gen.types, inputs[gen.pkg])
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := m.write(path, diagnostics); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got manifest
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Files) != 1 {
+		t.Fatalf("manifest files = %+v", got.Files)
+	}
+	f := got.Files[0]
+	sum := sha256.Sum256(code.Bytes())
+	want := manifestFile{
+		Path:    "testdata/broken/broken_deepcopy.go",
+		Package: pkg,
+		Types:   []string{"Good"},
+		Options: f.Options,
+		Inputs:  []string{"testdata/broken/broken.go"},
+		SHA256:  hex.EncodeToString(sum[:]),
+	}
+	if diff := cmp.Diff(f, want); diff != "" {
+		t.Errorf("manifest file diff = %s", diff)
+	}
+	if f.Options["method"] != "DeepCopy" || f.Options["maxdepth"] != "0" {
+		t.Errorf("manifest options = %v", f.Options)
+	}
+
+	if len(got.Diagnostics) == 0 || !strings.Contains(got.Diagnostics[0], "undefined: Missing") {
+		t.Errorf("manifest diagnostics = %q, want the package errors", got.Diagnostics)
+	}
+}
+
+func Test_reportResults(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	diagnostics := recordDiagnostics()
+
+	output := filepath.Join(t.TempDir(), "good_deepcopy.go")
+	results := []packageResult{
+		{path: "example.com/bad", err: errors.New("type Bad: field Items[i] has an invalid type")},
+		{
+			path:   "example.com/good",
+			output: output,
+			code:   []byte("package good\n"),
+			gen:    generated{pkg: "example.com/good", types: []string{"Good"}},
+		},
+	}
+
+	m, failed := reportResults(results, false, nil, diagnostics)
+	if failed != 1 {
+		t.Errorf("reportResults() failed = %d, want 1", failed)
+	}
+	if _, err := os.Stat(output); err != nil {
+		t.Errorf("reportResults() output: %v", err)
+	}
+
+	if len(m.Files) != 1 || m.Files[0].Package != "example.com/good" || !slices.Equal(m.Files[0].Types, []string{"Good"}) {
+		t.Errorf("manifest files = %+v", m.Files)
+	}
+
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	if err := m.write(path, diagnostics); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Error generating deep copy methods of example.com/bad: type Bad: field Items[i] has an invalid type"}
+	if diff := cmp.Diff(m.Diagnostics, want); diff != "" {
+		t.Errorf("manifest diagnostics diff = %s", diff)
+	}
+}
+
+func Test_outputTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		load deepcopy.LoadConfig
+		want []string
+	}{
+		{name: "none"},
+		{name: "explicit", tags: []string{"a", "b"}, load: deepcopy.LoadConfig{BuildTags: []string{"c"}, GOOS: "linux"}, want: []string{"a", "b"}},
+		{name: "inferred from tags", load: deepcopy.LoadConfig{BuildTags: []string{"enterprise,pro", "debug"}}, want: []string{"enterprise && pro && debug"}},
+		{name: "inferred from platform", load: deepcopy.LoadConfig{GOOS: "linux", GOARCH: "arm64", BuildTags: []string{"enterprise"}}, want: []string{"linux && arm64 && enterprise"}},
+		{name: "tests only", load: deepcopy.LoadConfig{Tests: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(outputTags(tt.tags, tt.load), tt.want); diff != "" {
+				t.Errorf("outputTags() diff = %s", diff)
+			}
+		})
+	}
+}
+
+func Test_headerTemplate(t *testing.T) {
+	saved := captureGlobals()
+	t.Cleanup(func() { restoreGlobals(saved) })
+
+	render := func(t *testing.T) string {
+		t.Helper()
+		tmpl, err := headerTemplate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tmpl == nil {
+			return ""
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, deepcopy.HeaderData{Package: "p"}); err != nil {
+			t.Fatal(err)
+		}
+		return b.String()
+	}
+
+	*headerFileF, headerText = "", ""
+	if got := render(t); got != "" {
+		t.Errorf("no header = %q, want none", got)
+	}
+
+	headerText = "inline {{.Package}}"
+	if got, want := render(t), "inline p"; got != want {
+		t.Errorf("inline header = %q, want %q", got, want)
+	}
+
+	*headerFileF = filepath.Join(t.TempDir(), "header.tmpl")
+	if err := os.WriteFile(*headerFileF, []byte("file {{.Package}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := render(t), "file p"; got != want {
+		t.Errorf("header file = %q, want %q", got, want)
+	}
+
+	if err := os.WriteFile(*headerFileF, []byte("{{.Package"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := headerTemplate(); err == nil {
+		t.Error("headerTemplate() of an invalid template = nil error")
+	}
+}
+
+func Test_testOutputPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"foo_gen.go":               "foo_gen_deepcopy_test.go",
+		"dir/foo_deepcopy.go":      "dir/foo_deepcopy_test.go",
+		"zz_generated.deepcopy":    "zz_generated.deepcopy_deepcopy_test.go",
+		"zz_generated_deepcopy.go": "zz_generated_deepcopy_test.go",
+	} {
+		if got := testOutputPath(path); got != want {
+			t.Errorf("testOutputPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
 var re = regexp.MustCompile(`Code generated by deep-copy.*; DO NOT EDIT.`)
 
 func normalizeComment(in []byte) []byte {
@@ -340,7 +916,7 @@ func (o SomeStruct2) DeepCopy() SomeStruct2 {
 	if o.mapStruct != nil {
 		cp.mapStruct = make(map[string]SomeStruct, len(o.mapStruct))
 		for k2, v2 := range o.mapStruct {
-			var cp_mapStruct_v2 SomeStruct
+			var cp_mapStruct_v2 SomeStruct = v2
 			if v2.mapSlice != nil {
 				cp_mapStruct_v2.mapSlice = make(map[string][]string, len(v2.mapSlice))
 				for k4, v4 := range v2.mapSlice {
@@ -633,4 +1209,811 @@ func (o Foo) DeepCopy() Foo {
 	}
 	return cp
 }`
+
+	K8sMarkers = `// Code generated by deep-copy; DO NOT EDIT.
+
+package v1
+
+import (
+	"github.com/globusdigital/deep-copy/testdata/k8s/runtime"
+)
+
+// DeepCopyInto copies the receiver into out. o must be non-nil.
+func (o *Widget) DeepCopyInto(out *Widget) {
+	*out = *o
+	if o.Labels != nil {
+		out.Labels = make(map[string]string, len(o.Labels))
+		for k2, v2 := range o.Labels {
+			out.Labels[k2] = v2
+		}
+	}
+	if o.Spec != nil {
+		out.Spec = o.Spec.DeepCopy()
+	}
+}
+
+// DeepCopy generates a deep copy of *Widget
+func (o *Widget) DeepCopy() *Widget {
+	if o == nil {
+		return nil
+	}
+	out := new(Widget)
+	o.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject generates a deep copy of *Widget as a runtime.Object.
+func (o *Widget) DeepCopyObject() runtime.Object {
+	if c := o.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto copies the receiver into out. o must be non-nil.
+func (o *Selector) DeepCopyInto(out *Selector) {
+	*out = *o
+	if *o != nil {
+		*out = make(map[string][]string, len(*o))
+		for k, v := range *o {
+			var out_v []string
+			if v != nil {
+				out_v = make([]string, len(v))
+				copy(out_v, v)
+			}
+			(*out)[k] = out_v
+		}
+	}
+}
+
+// DeepCopy generates a deep copy of *Selector
+func (o *Selector) DeepCopy() *Selector {
+	if o == nil {
+		return nil
+	}
+	out := new(Selector)
+	o.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into out. o must be non-nil.
+func (o *WidgetSpec) DeepCopyInto(out *WidgetSpec) {
+	*out = *o
+	if o.Replicas != nil {
+		out.Replicas = new(int32)
+		*out.Replicas = *o.Replicas
+	}
+	if o.Ports != nil {
+		out.Ports = make([]int, len(o.Ports))
+		copy(out.Ports, o.Ports)
+	}
+	{
+		retV := o.Selector.DeepCopy()
+		out.Selector = *retV
+	}
+}
+
+// DeepCopy generates a deep copy of *WidgetSpec
+func (o *WidgetSpec) DeepCopy() *WidgetSpec {
+	if o == nil {
+		return nil
+	}
+	out := new(WidgetSpec)
+	o.DeepCopyInto(out)
+	return out
+}`
+
+	ShadowedLocals = `// Code generated by deep-copy; DO NOT EDIT.
+
+package shadow
+
+// DeepCopy generates a deep copy of Shadowed
+func (o_ Shadowed) DeepCopy() Shadowed {
+	var cp Shadowed = o_
+	if o_.O != nil {
+		cp.O = new(o)
+		*cp.O = *o_.O
+	}
+	if o_.R != nil {
+		cp.R = make([]retV, len(o_.R))
+		copy(cp.R, o_.R)
+		for i2 := range o_.R {
+			{
+				retV_ := o_.R[i2].DeepCopy()
+				cp.R[i2] = *retV_
+			}
+		}
+	}
+	if o_.M != nil {
+		cp.M = make(map[k2]map[k2][]*int, len(o_.M))
+		for k2_, v2 := range o_.M {
+			var cp_M_v2 map[k2][]*int
+			if v2 != nil {
+				cp_M_v2 = make(map[k2][]*int, len(v2))
+				for k3, v3 := range v2 {
+					var cp_M_v2_v3 []*int
+					if v3 != nil {
+						cp_M_v2_v3 = make([]*int, len(v3))
+						copy(cp_M_v2_v3, v3)
+						for i4 := range v3 {
+							if v3[i4] != nil {
+								cp_M_v2_v3[i4] = new(int)
+								*cp_M_v2_v3[i4] = *v3[i4]
+							}
+						}
+					}
+					cp_M_v2[k3] = cp_M_v2_v3
+				}
+			}
+			cp.M[k2_] = cp_M_v2
+		}
+	}
+	if o_.MS != nil {
+		cp.MS = make(map[string]retV, len(o_.MS))
+		for k2_, v2 := range o_.MS {
+			var cp_MS_v2 retV
+			{
+				retV_ := v2.DeepCopy()
+				cp_MS_v2 = *retV_
+			}
+			cp.MS[k2_] = cp_MS_v2
+		}
+	}
+	return cp
+}`
+
+	AliasedFields = `// Code generated by deep-copy; DO NOT EDIT.
+
+package aliases
+
+// DeepCopy generates a deep copy of *Config
+func (o *Config) DeepCopy() *Config {
+	var cp Config = *o
+	if o.Name != nil {
+		cp.Name = new(string)
+		*cp.Name = *o.Name
+	}
+	cp.Item = o.Item.DeepCopy()
+	if o.Items != nil {
+		cp.Items = make([]Item, len(o.Items))
+		copy(cp.Items, o.Items)
+		for i2 := range o.Items {
+			cp.Items[i2] = o.Items[i2].DeepCopy()
+		}
+	}
+	if o.Ref != nil {
+		cp.Ref = o.Ref.DeepCopy()
+	}
+	if o.Refs != nil {
+		cp.Refs = make(map[string]*Ref, len(o.Refs))
+		for k2, v2 := range o.Refs {
+			var cp_Refs_v2 *Ref
+			if v2 != nil {
+				cp_Refs_v2 = v2.DeepCopy()
+			}
+			cp.Refs[k2] = cp_Refs_v2
+		}
+	}
+	return &cp
+}`
+	GenericAliases = `// Code generated by deep-copy; DO NOT EDIT.
+
+package aliases
+
+// DeepCopy generates a deep copy of Index
+func (o Index) DeepCopy() Index {
+	var cp Index = o
+	if o.Seen != nil {
+		cp.Seen = make(map[string]struct{}, len(o.Seen))
+		for k2, v2 := range o.Seen {
+			cp.Seen[k2] = v2
+		}
+	}
+	if o.Groups != nil {
+		cp.Groups = make(map[string]Set[string], len(o.Groups))
+		for k2, v2 := range o.Groups {
+			var cp_Groups_v2 Set[string]
+			if v2 != nil {
+				cp_Groups_v2 = make(map[string]struct{}, len(v2))
+				for k3, v3 := range v2 {
+					cp_Groups_v2[k3] = v3
+				}
+			}
+			cp.Groups[k2] = cp_Groups_v2
+		}
+	}
+	return cp
+}`
+	FooCloneSlicesFile = `// Code generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"slices"
+)
+
+// DeepCopy generates a deep copy of Foo
+func (o Foo) DeepCopy() Foo {
+	var cp Foo = o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				cp_Map_v2.Slice = slices.Clone(v2.Slice)
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return cp
+}`
+	Issue12NestedSlicesClone = `// Code generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"slices"
+)
+
+// DeepCopy generates a deep copy of I12NestedSlices
+func (o I12NestedSlices) DeepCopy() I12NestedSlices {
+	var cp I12NestedSlices = o
+	if o.Slices != nil {
+		cp.Slices = make([][][]int, len(o.Slices))
+		copy(cp.Slices, o.Slices)
+		for i2 := range o.Slices {
+			if o.Slices[i2] != nil {
+				cp.Slices[i2] = make([][]int, len(o.Slices[i2]))
+				copy(cp.Slices[i2], o.Slices[i2])
+				for i3 := range o.Slices[i2] {
+					cp.Slices[i2][i3] = slices.Clone(o.Slices[i2][i3])
+				}
+			}
+		}
+	}
+	return cp
+}`
+	Issue3MapSimpleStructValClone = `// Code generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"maps"
+)
+
+// DeepCopy generates a deep copy of I3WithMapVal
+func (o I3WithMapVal) DeepCopy() I3WithMapVal {
+	var cp I3WithMapVal = o
+	cp.a = maps.Clone(o.a)
+	return cp
+}`
+	ShadowedImports = `// Code generated by deep-copy; DO NOT EDIT.
+
+package shadow
+
+import (
+	maps_ "maps"
+	slices_ "slices"
+)
+
+// DeepCopy generates a deep copy of ShadowedImports
+func (o_ ShadowedImports) DeepCopy() ShadowedImports {
+	var cp ShadowedImports = o_
+	cp.S = slices_.Clone(o_.S)
+	cp.M = maps_.Clone(o_.M)
+	return cp
+}`
+	SelectionAll = `// Code generated by deep-copy; DO NOT EDIT.
+
+package selection
+
+// DeepCopy generates a deep copy of CreateReq
+func (o CreateReq) DeepCopy() CreateReq {
+	var cp CreateReq = o
+	if o.Name != nil {
+		cp.Name = new(string)
+		*cp.Name = *o.Name
+	}
+	return cp
+}
+
+// DeepCopy generates a deep copy of CreateResp
+func (o CreateResp) DeepCopy() CreateResp {
+	var cp CreateResp = o
+	if o.IDs != nil {
+		cp.IDs = make([]int, len(o.IDs))
+		copy(cp.IDs, o.IDs)
+	}
+	return cp
+}
+
+// DeepCopy generates a deep copy of Server
+func (o Server) DeepCopy() Server {
+	var cp Server = o
+	if o.Addr != nil {
+		cp.Addr = new(string)
+		*cp.Addr = *o.Addr
+	}
+	return cp
+}`
+	SelectionPattern = `// Code generated by deep-copy; DO NOT EDIT.
+
+package selection
+
+// DeepCopy generates a deep copy of CreateReq
+func (o CreateReq) DeepCopy() CreateReq {
+	var cp CreateReq = o
+	if o.Name != nil {
+		cp.Name = new(string)
+		*cp.Name = *o.Name
+	}
+	return cp
+}`
+	SelectionPatternExplicit = `// Code generated by deep-copy; DO NOT EDIT.
+
+package selection
+
+// DeepCopy generates a deep copy of Server
+func (o Server) DeepCopy() Server {
+	var cp Server = o
+	if o.Addr != nil {
+		cp.Addr = new(string)
+		*cp.Addr = *o.Addr
+	}
+	return cp
+}
+
+// DeepCopy generates a deep copy of CreateReq
+func (o CreateReq) DeepCopy() CreateReq {
+	var cp CreateReq = o
+	if o.Name != nil {
+		cp.Name = new(string)
+		*cp.Name = *o.Name
+	}
+	return cp
+}`
+	QualifiedType = `// Code generated by deep-copy; DO NOT EDIT.
+
+package lookup
+
+// DeepCopy generates a deep copy of Order
+func (o Order) DeepCopy() Order {
+	var cp Order = o
+	if o.Items != nil {
+		cp.Items = make([]*OrderItem, len(o.Items))
+		copy(cp.Items, o.Items)
+		for i2 := range o.Items {
+			if o.Items[i2] != nil {
+				cp.Items[i2] = new(OrderItem)
+				*cp.Items[i2] = *o.Items[i2]
+				if o.Items[i2].Tags != nil {
+					cp.Items[i2].Tags = make([]string, len(o.Items[i2].Tags))
+					copy(cp.Items[i2].Tags, o.Items[i2].Tags)
+				}
+			}
+		}
+	}
+	cp.Extra = o.Extra.DeepCopy()
+	return cp
+}`
+	TestsInternalVariant = `// Code generated by deep-copy; DO NOT EDIT.
+
+package orders
+
+// DeepCopy generates a deep copy of fixture
+func (o fixture) DeepCopy() fixture {
+	var cp fixture = o
+	if o.Order != nil {
+		cp.Order = new(Order)
+		*cp.Order = *o.Order
+		if o.Order.Lines != nil {
+			cp.Order.Lines = make([]*Line, len(o.Order.Lines))
+			copy(cp.Order.Lines, o.Order.Lines)
+			for i4 := range o.Order.Lines {
+				if o.Order.Lines[i4] != nil {
+					cp.Order.Lines[i4] = new(Line)
+					*cp.Order.Lines[i4] = *o.Order.Lines[i4]
+					if o.Order.Lines[i4].Qty != nil {
+						cp.Order.Lines[i4].Qty = new(int)
+						*cp.Order.Lines[i4].Qty = *o.Order.Lines[i4].Qty
+					}
+				}
+			}
+		}
+	}
+	if o.Notes != nil {
+		cp.Notes = make(map[string][]string, len(o.Notes))
+		for k2, v2 := range o.Notes {
+			var cp_Notes_v2 []string
+			if v2 != nil {
+				cp_Notes_v2 = make([]string, len(v2))
+				copy(cp_Notes_v2, v2)
+			}
+			cp.Notes[k2] = cp_Notes_v2
+		}
+	}
+	return cp
+}`
+	TestsExternalPackage = `// Code generated by deep-copy; DO NOT EDIT.
+
+package orders_test
+
+import (
+	orders "github.com/globusdigital/deep-copy/testdata/testvariants"
+)
+
+// DeepCopy generates a deep copy of Case
+func (o Case) DeepCopy() Case {
+	var cp Case = o
+	if o.Input != nil {
+		cp.Input = new(orders.Order)
+		*cp.Input = *o.Input
+		if o.Input.Lines != nil {
+			cp.Input.Lines = make([]*orders.Line, len(o.Input.Lines))
+			copy(cp.Input.Lines, o.Input.Lines)
+			for i4 := range o.Input.Lines {
+				if o.Input.Lines[i4] != nil {
+					cp.Input.Lines[i4] = new(orders.Line)
+					*cp.Input.Lines[i4] = *o.Input.Lines[i4]
+					if o.Input.Lines[i4].Qty != nil {
+						cp.Input.Lines[i4].Qty = new(int)
+						*cp.Input.Lines[i4].Qty = *o.Input.Lines[i4].Qty
+					}
+				}
+			}
+		}
+	}
+	if o.Want != nil {
+		cp.Want = make([]orders.Order, len(o.Want))
+		copy(cp.Want, o.Want)
+		for i2 := range o.Want {
+			if o.Want[i2].Lines != nil {
+				cp.Want[i2].Lines = make([]*orders.Line, len(o.Want[i2].Lines))
+				copy(cp.Want[i2].Lines, o.Want[i2].Lines)
+				for i4 := range o.Want[i2].Lines {
+					if o.Want[i2].Lines[i4] != nil {
+						cp.Want[i2].Lines[i4] = new(orders.Line)
+						*cp.Want[i2].Lines[i4] = *o.Want[i2].Lines[i4]
+						if o.Want[i2].Lines[i4].Qty != nil {
+							cp.Want[i2].Lines[i4].Qty = new(int)
+							*cp.Want[i2].Lines[i4].Qty = *o.Want[i2].Lines[i4].Qty
+						}
+					}
+				}
+			}
+		}
+	}
+	return cp
+}`
+	PlatformLicense = `// Code generated by deep-copy; DO NOT EDIT.
+
+//go:build linux && enterprise
+// +build linux,enterprise
+
+package platform
+
+// DeepCopy generates a deep copy of License
+//
+func (o License) DeepCopy() License {
+	var cp License = o
+	if o.Seats != nil {
+		cp.Seats = new(int)
+		*cp.Seats = *o.Seats
+	}
+	if o.Keys != nil {
+		cp.Keys = make([]*string, len(o.Keys))
+		copy(cp.Keys, o.Keys)
+		for i2 := range o.Keys {
+			if o.Keys[i2] != nil {
+				cp.Keys[i2] = new(string)
+				*cp.Keys[i2] = *o.Keys[i2]
+			}
+		}
+	}
+	return cp
+}`
+	PlatformPlan9 = `// Code generated by deep-copy; DO NOT EDIT.
+
+//go:build plan9
+// +build plan9
+
+package platform
+
+// DeepCopy generates a deep copy of Plan9
+//
+func (o Plan9) DeepCopy() Plan9 {
+	var cp Plan9 = o
+	if o.Dir != nil {
+		cp.Dir = new(string)
+		*cp.Dir = *o.Dir
+	}
+	return cp
+}`
+	OverlayDraft = `// Code generated by deep-copy; DO NOT EDIT.
+
+package overlay
+
+// DeepCopy generates a deep copy of Draft
+func (o Draft) DeepCopy() Draft {
+	var cp Draft = o
+	if o.Model != nil {
+		cp.Model = new(Model)
+		*cp.Model = *o.Model
+		if o.Model.Fields != nil {
+			cp.Model.Fields = make(map[string][]string, len(o.Model.Fields))
+			for k4, v4 := range o.Model.Fields {
+				var cp_Model_Fields_v4 []string
+				if v4 != nil {
+					cp_Model_Fields_v4 = make([]string, len(v4))
+					copy(cp_Model_Fields_v4, v4)
+				}
+				cp.Model.Fields[k4] = cp_Model_Fields_v4
+			}
+		}
+	}
+	return cp
+}`
+
+	BrokenGood = `// Code generated by deep-copy; DO NOT EDIT.
+
+package broken
+
+// DeepCopy generates a deep copy of Good
+func (o Good) DeepCopy() Good {
+	var cp Good = o
+	if o.Tags != nil {
+		cp.Tags = make([]string, len(o.Tags))
+		copy(cp.Tags, o.Tags)
+	}
+	if o.Ptr != nil {
+		cp.Ptr = new(int)
+		*cp.Ptr = *o.Ptr
+	}
+	return cp
+}`
+	FooPointerEqualNilEmpty = `// Code generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+// DeepCopy generates a deep copy of *Foo
+func (o *Foo) DeepCopy() *Foo {
+	var cp Foo = *o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				if v2.Slice != nil {
+					cp_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(cp_Map_v2.Slice, v2.Slice)
+				}
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return &cp
+}
+
+// Equal reports whether *Foo and other are deeply equal
+func (o *Foo) Equal(other *Foo) bool {
+	if o == nil || other == nil {
+		return o == other
+	}
+	if len(o.Map) != len(other.Map) {
+		return false
+	}
+	for k2, va2 := range o.Map {
+		vb2, ok := other.Map[k2]
+		if !ok {
+			return false
+		}
+		if (va2 == nil) != (vb2 == nil) {
+			return false
+		}
+		if va2 != nil {
+			if va2.IntV != vb2.IntV {
+				return false
+			}
+			if len(va2.Slice) != len(vb2.Slice) {
+				return false
+			}
+			for i5 := range va2.Slice {
+				if va2.Slice[i5] != vb2.Slice[i5] {
+					return false
+				}
+			}
+		}
+	}
+	if (o.ch == nil) != (other.ch == nil) {
+		return false
+	}
+	if o.baz.String != other.baz.String {
+		return false
+	}
+	if (o.baz.StringPointer == nil) != (other.baz.StringPointer == nil) {
+		return false
+	}
+	if o.baz.StringPointer != nil {
+		if *o.baz.StringPointer != *other.baz.StringPointer {
+			return false
+		}
+	}
+	return true
+}`
+	FooPointerDiffNilEmpty = `// Code generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"github.com/globusdigital/deep-copy/deepcopy"
+)
+
+// DeepCopy generates a deep copy of *Foo
+func (o *Foo) DeepCopy() *Foo {
+	var cp Foo = *o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				if v2.Slice != nil {
+					cp_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(cp_Map_v2.Slice, v2.Slice)
+				}
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return &cp
+}
+
+// Diff reports the changes from *Foo to other
+func (o *Foo) Diff(other *Foo) []deepcopy.Change {
+	var changes []deepcopy.Change
+	if o == nil || other == nil {
+		if o != other {
+			changes = append(changes, deepcopy.Change{Selector: "", Kind: deepcopy.Modified, Old: o, New: other})
+		}
+		return changes
+	}
+	n_o_Map := len(changes)
+	for k2, va2 := range o.Map {
+		vb2, ok := other.Map[k2]
+		if !ok {
+			changes = append(changes, deepcopy.Change{Selector: "Map[k]", Keys: []any{k2}, Kind: deepcopy.Removed, Old: va2})
+			continue
+		}
+		if (va2 == nil) != (vb2 == nil) {
+			changes = append(changes, deepcopy.Change{Selector: "Map[k]", Keys: []any{k2}, Kind: deepcopy.Modified, Old: va2, New: vb2})
+		} else if va2 != nil {
+			if va2.IntV != vb2.IntV {
+				changes = append(changes, deepcopy.Change{Selector: "Map[k].IntV", Keys: []any{k2}, Kind: deepcopy.Modified, Old: va2.IntV, New: vb2.IntV})
+			}
+			for i5 := range va2.Slice {
+				if i5 >= len(vb2.Slice) {
+					changes = append(changes, deepcopy.Change{Selector: "Map[k].Slice[i]", Keys: []any{k2, i5}, Kind: deepcopy.Removed, Old: va2.Slice[i5]})
+					continue
+				}
+				if va2.Slice[i5] != vb2.Slice[i5] {
+					changes = append(changes, deepcopy.Change{Selector: "Map[k].Slice[i]", Keys: []any{k2, i5}, Kind: deepcopy.Modified, Old: va2.Slice[i5], New: vb2.Slice[i5]})
+				}
+			}
+			for i5 := len(va2.Slice); i5 < len(vb2.Slice); i5++ {
+				changes = append(changes, deepcopy.Change{Selector: "Map[k].Slice[i]", Keys: []any{k2, i5}, Kind: deepcopy.Added, New: vb2.Slice[i5]})
+			}
+		}
+	}
+	for k2, vb2 := range other.Map {
+		if _, ok := o.Map[k2]; !ok {
+			changes = append(changes, deepcopy.Change{Selector: "Map[k]", Keys: []any{k2}, Kind: deepcopy.Added, New: vb2})
+		}
+	}
+	deepcopy.SortChanges(changes[n_o_Map:])
+	if (o.ch == nil) != (other.ch == nil) {
+		changes = append(changes, deepcopy.Change{Selector: "ch", Kind: deepcopy.Modified, Old: o.ch, New: other.ch})
+	}
+	if o.baz.String != other.baz.String {
+		changes = append(changes, deepcopy.Change{Selector: "baz.String", Kind: deepcopy.Modified, Old: o.baz.String, New: other.baz.String})
+	}
+	if (o.baz.StringPointer == nil) != (other.baz.StringPointer == nil) {
+		changes = append(changes, deepcopy.Change{Selector: "baz.StringPointer", Kind: deepcopy.Modified, Old: o.baz.StringPointer, New: other.baz.StringPointer})
+	} else if o.baz.StringPointer != nil {
+		if *o.baz.StringPointer != *other.baz.StringPointer {
+			changes = append(changes, deepcopy.Change{Selector: "baz.StringPointer", Kind: deepcopy.Modified, Old: *o.baz.StringPointer, New: *other.baz.StringPointer})
+		}
+	}
+	return changes
+}`
+	FooPointerSize = `// Code generated by deep-copy; DO NOT EDIT.
+
+package testdata
+
+import (
+	"unsafe"
+)
+
+// DeepCopy generates a deep copy of *Foo
+func (o *Foo) DeepCopy() *Foo {
+	var cp Foo = *o
+	if o.Map != nil {
+		cp.Map = make(map[string]*Bar, len(o.Map))
+		for k2, v2 := range o.Map {
+			var cp_Map_v2 *Bar
+			if v2 != nil {
+				cp_Map_v2 = new(Bar)
+				*cp_Map_v2 = *v2
+				if v2.Slice != nil {
+					cp_Map_v2.Slice = make([]string, len(v2.Slice))
+					copy(cp_Map_v2.Slice, v2.Slice)
+				}
+			}
+			cp.Map[k2] = cp_Map_v2
+		}
+	}
+	if o.ch != nil {
+		cp.ch = make(chan float32, cap(o.ch))
+	}
+	if o.baz.StringPointer != nil {
+		cp.baz.StringPointer = new(string)
+		*cp.baz.StringPointer = *o.baz.StringPointer
+	}
+	return &cp
+}
+
+// DeepSize estimates the bytes *Foo and the memory its deep copy allocates hold
+func (o *Foo) DeepSize() int {
+	if o == nil {
+		return 0
+	}
+	size := int(unsafe.Sizeof(*o))
+	if o.Map != nil {
+		size += 48 + len(o.Map)*(int(unsafe.Sizeof(*new(string)))+int(unsafe.Sizeof(*new(*Bar)))+1)
+	}
+	for k2, v2 := range o.Map {
+		size += len(k2)
+		if v2 != nil {
+			size += int(unsafe.Sizeof(*v2))
+			size += cap(v2.Slice) * int(unsafe.Sizeof(v2.Slice[0]))
+			for i5 := range v2.Slice {
+				size += len(v2.Slice[i5])
+			}
+		}
+	}
+	if o.ch != nil {
+		size += 96 + cap(o.ch)*int(unsafe.Sizeof(*new(float32)))
+	}
+	size += len(o.baz.String)
+	if o.baz.StringPointer != nil {
+		size += int(unsafe.Sizeof(*o.baz.StringPointer))
+		size += len(*o.baz.StringPointer)
+	}
+	return size
+}`
 )
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/globusdigital/deep-copy/deepcopy"
+)
+
+// manifest records the files a run generated and the inputs they were
+// generated from, for build systems caching them. It is written by
+// --manifest.
+type manifest struct {
+	Files []manifestFile `json:"files"`
+	// Diagnostics are the warnings logged during the run, and the errors of
+	// the packages that failed among several.
+	Diagnostics []string `json:"diagnostics"`
+}
+
+// manifestFile is a generated file of a manifest.
+type manifestFile struct {
+	Path    string   `json:"path"`
+	Package string   `json:"package"`
+	Types   []string `json:"types"`
+	// Options are the values of all the flags, once merged with the
+	// configuration file.
+	Options map[string]string `json:"options"`
+	// Inputs are the source files of the package, and the configuration
+	// and overlay files, if any.
+	Inputs []string `json:"inputs"`
+	SHA256 string   `json:"sha256"`
+}
+
+// add records the generated file at path, with its content, generated for
+// the types of the package from the inputs. Standard output is recorded as
+// "-".
+func (m *manifest) add(path, pkg string, code []byte, types, inputs []string) {
+	if path != "-" {
+		path = relPath(path)
+	}
+	if inputs == nil {
+		inputs = []string{}
+	}
+	sum := sha256.Sum256(code)
+
+	m.Files = append(m.Files, manifestFile{
+		Path:    path,
+		Package: pkg,
+		Types:   types,
+		Options: effectiveOptions(),
+		Inputs:  inputs,
+		SHA256:  hex.EncodeToString(sum[:]),
+	})
+}
+
+// write writes the manifest to path as indented JSON, along with the
+// warnings recorded by d. The generated files are left out of the inputs, as
+// they are excluded while loading.
+func (m *manifest) write(path string, d *diagnosticsLog) error {
+	d.mu.Lock()
+	m.Diagnostics = slices.Clone(d.warnings)
+	d.mu.Unlock()
+
+	generated := map[string]bool{}
+	for _, f := range m.Files {
+		generated[f.Path] = true
+	}
+	for i, f := range m.Files {
+		m.Files[i].Inputs = slices.DeleteFunc(slices.Clone(f.Inputs), func(in string) bool {
+			return generated[in]
+		})
+	}
+
+	if m.Files == nil {
+		m.Files = []manifestFile{}
+	}
+	if m.Diagnostics == nil {
+		m.Diagnostics = []string{}
+	}
+
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(b, '\n'), 0o666)
+}
+
+// effectiveOptions returns the values of all the flags.
+func effectiveOptions() map[string]string {
+	opts := map[string]string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		opts[f.Name] = f.Value.String()
+	})
+
+	return opts
+}
+
+// inputFiles returns the source files of the packages matching the patterns,
+// by import path, along with the configuration and overlay files, sorted. The
+// files of an external test package are listed with the package it tests.
+func inputFiles(cfg deepcopy.LoadConfig, patterns ...string) (map[string][]string, error) {
+	pkgFiles, err := deepcopy.Files(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	var extra []string
+	for _, f := range []string{*configFileF, *overlayF, *headerFileF} {
+		if f != "" {
+			extra = append(extra, relPath(f))
+		}
+	}
+
+	files := map[string][]string{}
+	for path, fs := range pkgFiles {
+		path = strings.TrimSuffix(path, "_test")
+		for _, f := range fs {
+			files[path] = append(files[path], relPath(f))
+		}
+	}
+	for path := range files {
+		files[path] = append(files[path], extra...)
+		slices.Sort(files[path])
+	}
+
+	return files, nil
+}
+
+// relPath returns path relative to the working directory when it is within
+// it, so that manifests don't depend on where the tree is checked out.
+func relPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return abs
+	}
+
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// diagnosticsLog records the warnings logged, along with writing the log
+// to w, and the errors added to it.
+type diagnosticsLog struct {
+	w io.Writer
+
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (d *diagnosticsLog) Write(b []byte) (int, error) {
+	line := strings.TrimSuffix(string(b), "\n")
+	if _, warning, ok := strings.Cut(line, "WARNING: "); ok {
+		d.add(warning)
+	}
+
+	return d.w.Write(b)
+}
+
+// add records a diagnostic that is not logged as a warning, such as the
+// error of a package that failed among several.
+func (d *diagnosticsLog) add(diagnostic string) {
+	d.mu.Lock()
+	d.warnings = append(d.warnings, diagnostic)
+	d.mu.Unlock()
+}
+
+// recordDiagnostics records the warnings logged from then on.
+func recordDiagnostics() *diagnosticsLog {
+	d := &diagnosticsLog{w: log.Writer()}
+	log.SetOutput(d)
+
+	return d
+}
@@ -0,0 +1,20 @@
+package aliases
+
+import "github.com/globusdigital/deep-copy/testdata/aliases/other"
+
+type Config struct {
+	Name  *string
+	Item  other.Exported
+	Items []Item
+	Ref   *Ref
+	Refs  Refs
+}
+
+type (
+	Item = other.Item
+	Ref  = Config
+	Refs = map[string]*Ref
+)
+
+// Widget is an alias of a local named type, generating the methods of Config.
+type Widget = Config
@@ -0,0 +1,10 @@
+//go:build go1.24
+
+package aliases
+
+type Set[T comparable] = map[T]struct{}
+
+type Index struct {
+	Seen   Set[string]
+	Groups map[string]Set[string]
+}
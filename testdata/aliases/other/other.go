@@ -0,0 +1,15 @@
+package other
+
+type Item struct {
+	Tags []string
+}
+
+func (i Item) DeepCopy() Item {
+	cp := i
+	if i.Tags != nil {
+		cp.Tags = append([]string(nil), i.Tags...)
+	}
+	return cp
+}
+
+type Exported = Item
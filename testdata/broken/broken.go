@@ -0,0 +1,18 @@
+package broken
+
+// Good only has valid fields, and can be generated despite the errors of the
+// package.
+type Good struct {
+	Tags []string
+	Ptr  *int
+}
+
+// Bad refers to a type that does not exist.
+type Bad struct {
+	Name  string
+	Items []Missing
+}
+
+func unrelated() int {
+	return "unrelated"
+}
@@ -0,0 +1,26 @@
+package convert
+
+type Status string
+
+type List struct {
+	Head Node
+}
+
+type Node struct {
+	Value int
+	Next  *Node
+}
+
+type OtherList struct {
+	Head OtherNode
+}
+
+type OtherNode struct {
+	Value int
+	Next  *OtherNode
+}
+
+type Tagged struct {
+	A int `deepcopy:"name=C"`
+	C int
+}
@@ -0,0 +1,33 @@
+package explain
+
+import "time"
+
+type Plan struct {
+	Name  string
+	Next  *Plan
+	Steps []Step
+	Index map[string]*Step
+	At    time.Time
+	Hook  func()
+	Notes []string
+	Level Level1
+	Slots [2]*Step
+}
+
+type Step struct {
+	Name string
+	Tags []string
+}
+
+func (s Step) DeepCopy() Step {
+	s.Tags = append([]string(nil), s.Tags...)
+	return s
+}
+
+type Level1 struct {
+	Next *Level2
+}
+
+type Level2 struct {
+	Values []int
+}
@@ -0,0 +1,4 @@
+// +k8s:deepcopy-gen=package,register
+
+// Package v1 is a stub API group using deepcopy-gen markers.
+package v1
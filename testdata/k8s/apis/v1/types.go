@@ -0,0 +1,40 @@
+package v1
+
+import (
+	_ "github.com/globusdigital/deep-copy/testdata/k8s/runtime"
+)
+
+// Widget is a top level API object.
+// +k8s:deepcopy-gen:interfaces=github.com/globusdigital/deep-copy/testdata/k8s/runtime.Object
+// +k8s:deepcopy-gen:nonpointer-interfaces=true
+type Widget struct {
+	Name   string
+	Labels map[string]string
+	Spec   *WidgetSpec
+}
+
+// Selector lists label values by key.
+type Selector map[string][]string
+
+type WidgetSpec struct {
+	Replicas *int32
+	Ports    []int
+	Selector Selector
+}
+
+// +k8s:deepcopy-gen=false
+type widgetCache struct {
+	items map[string]*Widget
+}
+
+type Handcrafted struct {
+	Data []byte
+}
+
+func (h *Handcrafted) DeepCopy() *Handcrafted {
+	return &Handcrafted{Data: append([]byte(nil), h.Data...)}
+}
+
+type Phase interface {
+	Name() string
+}
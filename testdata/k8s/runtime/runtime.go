@@ -0,0 +1,6 @@
+package runtime
+
+// Object is a stub of k8s.io/apimachinery/pkg/runtime.Object.
+type Object interface {
+	DeepCopyObject() Object
+}
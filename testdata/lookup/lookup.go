@@ -0,0 +1,29 @@
+package lookup
+
+import "github.com/globusdigital/deep-copy/testdata/aliases/other"
+
+type OrderItem struct {
+	Tags []string
+}
+
+type OrderItems []*OrderItem
+
+type Order struct {
+	Items OrderItems
+	Extra other.Item
+}
+
+type Shape interface {
+	Area() float64
+}
+
+var DefaultOrder Order
+
+func NewOrder() Order {
+	// Local is declared in a function, and is never looked up.
+	type Local struct {
+		P *int
+	}
+
+	return Order{}
+}
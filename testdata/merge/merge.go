@@ -0,0 +1,9 @@
+package merge
+
+type UnknownPolicy struct {
+	Name string `merge:"keep"`
+}
+
+type AppendToMap struct {
+	Env map[string]string `merge:"append"`
+}
@@ -0,0 +1,7 @@
+// Package overlay is loaded with the unsaved buffers of the buffers
+// directory, replacing draft.go and adding model.go.
+package overlay
+
+type Draft struct {
+	Name string
+}
@@ -0,0 +1,9 @@
+//go:build enterprise
+
+package platform
+
+// License is only declared on linux, with the enterprise tag.
+type License struct {
+	Seats *int
+	Keys  []*string
+}
@@ -0,0 +1,5 @@
+package platform
+
+type Common struct {
+	Name *string
+}
@@ -0,0 +1,6 @@
+package platform
+
+// Plan9 is only declared on plan9, by the file name suffix.
+type Plan9 struct {
+	Dir *string
+}
@@ -0,0 +1,37 @@
+package selection
+
+type CreateReq struct {
+	Name *string
+}
+
+type CreateResp struct {
+	IDs []int
+}
+
+// ListReq has a hand-written DeepCopy method, which is never redeclared.
+type ListReq struct {
+	Filter *string
+}
+
+func (r ListReq) DeepCopy() ListReq {
+	cp := r
+	if r.Filter != nil {
+		cp.Filter = new(string)
+		*cp.Filter = *r.Filter
+	}
+	return cp
+}
+
+type internalReq struct {
+	Token *string
+}
+
+type Server struct {
+	Addr *string
+}
+
+type Mode int
+
+type Page[T any] struct {
+	Items []T
+}
@@ -0,0 +1,36 @@
+package shadow
+
+// Names used by the generated code are declared at the package level, and
+// must not be shadowed by its local variables.
+type (
+	o    int
+	k2   int
+	retV struct{ p *int }
+
+	// slices and maps are the names of the packages whose Clone functions
+	// the generated code calls.
+	slices int
+	maps   int
+)
+
+func (r retV) DeepCopy() *retV {
+	cp := r
+	if r.p != nil {
+		cp.p = new(int)
+		*cp.p = *r.p
+	}
+	return &cp
+}
+
+type Shadowed struct {
+	_  *int
+	O  *o
+	R  []retV
+	M  map[k2]map[k2][]*int
+	MS map[string]retV
+}
+
+type ShadowedImports struct {
+	S []slices
+	M map[k2]maps
+}
@@ -0,0 +1,9 @@
+package orders
+
+type Order struct {
+	Lines []*Line
+}
+
+type Line struct {
+	Qty *int
+}
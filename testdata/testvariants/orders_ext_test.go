@@ -0,0 +1,10 @@
+package orders_test
+
+import "github.com/globusdigital/deep-copy/testdata/testvariants"
+
+// Case is a test helper type of the external test package.
+type Case struct {
+	Name  string
+	Input *orders.Order
+	Want  []orders.Order
+}
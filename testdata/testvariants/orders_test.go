@@ -0,0 +1,7 @@
+package orders
+
+// fixture is a test helper type of the package.
+type fixture struct {
+	Order *Order
+	Notes map[string][]string
+}